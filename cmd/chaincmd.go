@@ -0,0 +1,160 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core"
+)
+
+const commandUsage = `commands:
+  export <file> [from] [to]   write canonical blocks to file, gzipped if it ends in .gz
+  import <file>               insert the blocks of an export file
+  snapshot export <file>      write every account at the head to file
+  snapshot import <file> [hash]
+                              store the state of a snapshot file taken at a
+                              stored block, or at the trusted block hash. an
+                              empty chain starts at that block
+  db inspect                  count keys and bytes per database namespace
+  db get <key>                print the value of a key, given in hex or as text
+  db dump <namespace> [limit] print the keys of a namespace and their values
+  db verify                   check the canonical chain block by block
+  db repair                   rewind the head to the last consistent block
+  db backup <dir>             copy the database into dir, also while a node runs
+  db restore <dir>            replace the database by the backup in dir`
+
+// runs the subcommand named by args[0], exiting the process on failure
+func runCommand(args []string) {
+	var err error
+	switch args[0] {
+	case "export":
+		err = exportChain(args[1:])
+	case "import":
+		err = importChain(args[1:])
+	case "snapshot":
+		err = snapshotCommand(args[1:])
+	case "db":
+		err = dbCommand(args[1:])
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", args[0], commandUsage)
+	}
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// opens the chain on disk, which the local wallet started as its validator.
+// a read-only chain can be opened next to a running node
+func openChain(readOnly bool) (*core.BlockChain, error) {
+	opts := nodeOptions()
+	opts.ReadOnly = readOnly
+	return core.NewChainWithConfig(chainConfig(localValidator()), opts)
+}
+
+func exportChain(args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return fmt.Errorf("usage: export <file> [from] [to]")
+	}
+	chain, err := openChain(true)
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+
+	from, to := uint64(1), chain.CurrentHeight()
+	if len(args) > 1 {
+		if from, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return err
+		}
+	}
+	if len(args) > 2 {
+		if to, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+			return err
+		}
+	}
+	return chain.ExportFile(args[0], from, to, nil)
+}
+
+func importChain(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: import <file>")
+	}
+	chain, err := openChain(false)
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+
+	return chain.ImportFile(args[0])
+}
+
+func snapshotCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: snapshot export <file> | snapshot import <file> [hash]")
+	}
+	chain, err := openChain(args[0] == "export")
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+
+	switch args[0] {
+	case "export":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: snapshot export <file>")
+		}
+		return chain.ExportSnapshotFile(args[1])
+	case "import":
+		if len(args) > 3 {
+			return fmt.Errorf("usage: snapshot import <file> [hash]")
+		}
+		var trusted common.Hash
+		if len(args) == 3 {
+			hash, err := hex.DecodeString(strings.TrimPrefix(args[2], "0x"))
+			if err != nil || len(hash) != common.HashLength {
+				return fmt.Errorf("invalid block hash %q", args[2])
+			}
+			trusted = common.Hash(hash)
+		}
+		b, err := chain.ImportSnapshotFile(args[1], trusted)
+		if err != nil {
+			return err
+		}
+		fmt.Println(b.Height, b.Hash.Hex(), b.StateRoot.Hex())
+		return nil
+	default:
+		return fmt.Errorf("unknown snapshot command %q", args[0])
+	}
+}
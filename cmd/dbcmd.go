@@ -0,0 +1,192 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/PulseCoinOrg/nexacoin/core"
+)
+
+// runs one of the db subcommands
+func dbCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: db inspect|get|dump|verify|repair|backup|restore\n%s", commandUsage)
+	}
+	if args[0] == "restore" {
+		// restore replaces the database, so it must not be opened first
+		if len(args) != 2 {
+			return fmt.Errorf("usage: db restore <dir>")
+		}
+		return core.Restore(chainConfig(localValidator()), args[1], nodeOptions())
+	}
+	// only repair writes, everything else reads a view of the database so
+	// it can run next to a node
+	chain, err := openChain(args[0] != "repair")
+	if err != nil {
+		return err
+	}
+	defer chain.Close()
+
+	switch args[0] {
+	case "inspect":
+		return inspectDatabase(chain)
+	case "get":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: db get <key>")
+		}
+		return getKey(chain, args[1])
+	case "dump":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: db dump <namespace> [limit], namespaces: %s", strings.Join(core.Namespaces(), ", "))
+		}
+		limit := uint64(0)
+		if len(args) == 3 {
+			if limit, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+				return err
+			}
+		}
+		return dumpNamespace(chain, args[1], limit)
+	case "verify":
+		height, err := chain.VerifyChain()
+		if err != nil {
+			return fmt.Errorf("chain is consistent up to block %d: %w", height, err)
+		}
+		fmt.Printf("chain is consistent up to the head at block %d\n", height)
+		return nil
+	case "backup":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: db backup <dir>")
+		}
+		return chain.Backup(args[1])
+	case "repair":
+		before := chain.CurrentHeight()
+		height, err := chain.Repair()
+		if err != nil {
+			return err
+		}
+		if height == before {
+			fmt.Printf("nothing to repair, head stays at block %d\n", height)
+		} else {
+			fmt.Printf("rewound head from block %d to block %d\n", before, height)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown db command %q", args[0])
+}
+
+func inspectDatabase(chain *core.BlockChain) error {
+	stats, err := chain.InspectDatabase()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-14s %12s %14s\n", "namespace", "keys", "bytes")
+	var keys, size uint64
+	for _, stat := range stats {
+		fmt.Printf("%-14s %12d %14d\n", stat.Name, stat.Keys, stat.Size)
+		keys += stat.Keys
+		size += stat.Size
+	}
+	fmt.Printf("%-14s %12d %14d\n", "total", keys, size)
+	return nil
+}
+
+// keys can be given in hex, or as text for the named metadata keys
+func getKey(chain *core.BlockChain, arg string) error {
+	key, err := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+	if err != nil {
+		key = []byte(arg)
+	}
+	namespace, value, err := chain.DecodeKey(key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("namespace: %s\n", namespace)
+	return printValue(value)
+}
+
+func dumpNamespace(chain *core.BlockChain, name string, limit uint64) error {
+	return chain.DumpNamespace(name, limit, func(key []byte, value any, err error) {
+		fmt.Printf("%x\n", key)
+		if err != nil {
+			fmt.Printf("  undecodable: %v\n", err)
+			return
+		}
+		printValue(value)
+	})
+}
+
+func printValue(value any) error {
+	out, err := json.MarshalIndent(readable(reflect.ValueOf(value)), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// converts a decoded value into something that prints well as json, with
+// hashes, addresses and other byte strings in hex
+func readable(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return readable(v.Elem())
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return nil
+			}
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hex.EncodeToString(b)
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = readable(v.Index(i))
+		}
+		return items
+	case reflect.Struct:
+		fields := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fields[v.Type().Field(i).Name] = readable(v.Field(i))
+			}
+		}
+		return fields
+	}
+	return v.Interface()
+}
@@ -27,42 +27,119 @@
 package main
 
 import (
+	"flag"
 	"log/slog"
 	"time"
 
 	"github.com/PulseCoinOrg/nexacoin/core"
 	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
 	"github.com/PulseCoinOrg/nexacoin/wallet"
 )
 
+var (
+	indexAddresses   = flag.Bool("index.addresses", false, "keep an index of the transactions touching each address")
+	gcMode           = flag.String("gcmode", string(core.GCModeArchive), `keep all state ("archive") or only recent state ("pruned")`)
+	stateHistory     = flag.Uint64("state.history", core.DefaultStateHistory, "number of recent blocks whose state a pruned node keeps")
+	stateCheckpoints = flag.Uint64("state.checkpoints", core.DefaultStateCheckpoints, "interval of the checkpoint blocks whose state a pruned node keeps")
+)
+
+// stake the local validator starts the chain with
+const genesisStake = 32 * params.Nex
+
+// returns the config of the local chain, which starts with v as its only
+// validator. without a validator the chain has none
+func chainConfig(v *core.Validator) *params.ChainConfig {
+	config := *params.DefaultChainConfig
+	if v != nil {
+		config.Validators = []params.GenesisValidator{v.GenesisValidator(genesisStake)}
+	}
+	return &config
+}
+
+// returns the validator of the wallet on disk, nil if there is no wallet
+func localValidator() *core.Validator {
+	v, err := core.NewValidator()
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// builds the node options from the command line flags
+func nodeOptions() *core.Options {
+	return &core.Options{
+		IndexAddresses:   *indexAddresses,
+		GCMode:           core.GCMode(*gcMode),
+		StateHistory:     *stateHistory,
+		StateCheckpoints: *stateCheckpoints,
+	}
+}
+
 func main() {
+	flag.Parse()
+	if flag.NArg() > 0 {
+		runCommand(flag.Args())
+		return
+	}
+
 	w, err := wallet.New()
 	Handle(err)
 
 	err = w.SaveDisk()
 	Handle(err)
 
-	chain, err := core.NewChain()
-	Handle(err)
-
 	v, err := core.NewValidator()
 	Handle(err)
 
-	err = chain.Validators.AddValidator(v)
+	chain, err := core.NewChainWithConfig(chainConfig(v), nodeOptions())
 	Handle(err)
 
-	block1 := types.NewBlock(time.Now().Unix(), core.GenesisParentHash, []*types.Transaction{})
+	if pos, ok := chain.Engine.(*core.ProofOfStake); ok {
+		pos.Authorize(v)
+	}
+
+	block1 := types.NewBlock(1, time.Now().Unix(), core.GenesisParentHash, []*types.Transaction{})
+	err = chain.Engine.Prepare(chain, block1)
+	Handle(err)
+	err = chain.Assemble(block1)
+	Handle(err)
+	err = chain.Engine.Seal(chain, block1)
+	Handle(err)
 	err = chain.Insert(block1)
 	Handle(err)
 
-	block2 := types.NewBlock(time.Now().Unix(), block1.Hash, []*types.Transaction{})
+	block2 := types.NewBlock(2, time.Now().Unix(), block1.Hash, []*types.Transaction{})
+	err = chain.Engine.Prepare(chain, block2)
+	Handle(err)
+	err = chain.Assemble(block2)
+	Handle(err)
+	err = chain.Engine.Seal(chain, block2)
+	Handle(err)
 	err = chain.Insert(block2)
 	Handle(err)
 
-	block3 := types.NewBlock(time.Now().Unix(), block2.Hash, []*types.Transaction{})
+	block3 := types.NewBlock(3, time.Now().Unix(), block2.Hash, []*types.Transaction{})
+	err = chain.Engine.Prepare(chain, block3)
+	Handle(err)
+	err = chain.Assemble(block3)
+	Handle(err)
+	err = chain.Engine.Seal(chain, block3)
+	Handle(err)
 	err = chain.Insert(block3)
 	Handle(err)
 
+	for _, kind := range []types.VoteType{types.Prevote, types.Precommit} {
+		vote, err := v.SignVote(kind, 0, block3)
+		Handle(err)
+		err = chain.AddVote(vote)
+		Handle(err)
+	}
+
+	supply, err := chain.TotalSupply()
+	Handle(err)
+	slog.Info("total supply", "nex", supply/params.Nex)
+
 	valid := chain.ValidateLastBlock()
 	if !valid {
 		slog.Error("chain validator has found an invalid block")
@@ -0,0 +1,50 @@
+package lru
+
+import "testing"
+
+func TestCacheEviction(t *testing.T) {
+	c := New[int, string](2)
+	c.Add(1, "one")
+	c.Add(2, "two")
+
+	// reading 1 makes 2 the least recently used
+	if v, ok := c.Get(1); !ok || v != "one" {
+		t.Fatalf("Get(1) = %q, %v", v, ok)
+	}
+	c.Add(3, "three")
+	if c.Contains(2) {
+		t.Fatal("the least recently used entry was not evicted")
+	}
+	if !c.Contains(1) || !c.Contains(3) {
+		t.Fatal("a recently used entry was evicted")
+	}
+
+	// updating a key keeps the size and refreshes it
+	c.Add(1, "uno")
+	c.Add(4, "four")
+	if v, _ := c.Get(1); v != "uno" {
+		t.Fatalf("updated entry is %q", v)
+	}
+	if c.Contains(3) || c.Len() != 2 {
+		t.Fatalf("cache holds %d entries after the update", c.Len())
+	}
+
+	c.Remove(1)
+	if _, ok := c.Get(1); ok {
+		t.Fatal("removed entry is still cached")
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	c := New[string, int](0)
+	c.Add("a", 1)
+	c.Get("a")
+	c.Get("b")
+	c.Contains("b")
+	c.Purge()
+
+	want := Stats{Size: 1, Len: 0, Hits: 1, Misses: 1}
+	if got := c.Stats(); got != want {
+		t.Fatalf("stats %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package consensus
+
+import (
+	"errors"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+var (
+	ErrUnknownEngine = errors.New("unknown consensus engine")
+)
+
+// ChainReader is the read only view of the chain an engine gets
+type ChainReader interface {
+	// retrieves a block by hash, nil if it is unknown
+	GetBlock(hash common.Hash) *types.Block
+
+	// retrieves the canonical block at a height, nil if there is none
+	GetBlockByHeight(height uint64) *types.Block
+
+	// opens the state a block builds on, the state after its parent
+	ParentState(b *types.Block) (*state.StateDB, error)
+}
+
+// Engine is the consensus algorithm a chain runs. the chain calls into it to
+// build, check and accept blocks so it never needs to know which one is in use
+type Engine interface {
+	// returns the address of the account that produced the block
+	Author(b *types.Block) (common.Address, error)
+
+	// checks that a block follows the consensus rules of the engine
+	VerifyHeader(chain ChainReader, b *types.Block) error
+
+	// fills in the consensus fields of a block that is about to be built
+	Prepare(chain ChainReader, b *types.Block) error
+
+	// runs any consensus state changes once the chain has accepted a block
+	Finalize(chain ChainReader, b *types.Block) error
+
+	// completes a prepared block so that it passes VerifyHeader
+	Seal(chain ChainReader, b *types.Block) error
+}
@@ -0,0 +1,190 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+// role flags stored with each address index entry
+const (
+	addressSent     byte = 1 << 0
+	addressReceived byte = 1 << 1
+)
+
+// a canonical transaction that touched an address
+type AddressTx struct {
+	Height   uint64
+	Index    uint64
+	TxHash   common.Hash
+	Sent     bool
+	Received bool
+}
+
+// the entries a block adds to the address index, keyed by index key
+func addressIndexEntries(b *types.Block) map[string][]byte {
+	entries := make(map[string][]byte)
+	add := func(addr common.Address, i int, hash common.Hash, role byte) {
+		key := string(addressIndexKey(addr, b.Height, uint64(i)))
+		if value, ok := entries[key]; ok {
+			value[common.HashLength] |= role
+			return
+		}
+		entries[key] = append(append([]byte{}, hash.Bytes()...), role)
+	}
+	for i, tx := range b.Transactions {
+		add(tx.Sender, i, tx.Hash, addressSent)
+		add(tx.Recipient, i, tx.Hash, addressReceived)
+	}
+	return entries
+}
+
+func (chain *BlockChain) writeAddressIndex(db nexadb.KeyValueWriter, b *types.Block) error {
+	for key, value := range addressIndexEntries(b) {
+		if err := db.Put([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (chain *BlockChain) deleteAddressIndex(db nexadb.KeyValueWriter, b *types.Block) error {
+	for key := range addressIndexEntries(b) {
+		if err := db.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keeps the address index complete. a node opened without it drops the
+// marker that says the index is complete, as blocks it makes canonical are
+// not indexed. one opened with it and without the marker indexes the whole
+// canonical chain again, clearing out whatever an earlier run left behind
+func (chain *BlockChain) setupAddressIndex() error {
+	if chain.Options.ReadOnly {
+		return nil
+	}
+	indexed, err := chain.Database.Has(addressIndexedKey)
+	if err != nil {
+		return err
+	}
+	if !chain.Options.IndexAddresses {
+		if indexed {
+			return chain.Database.Delete(addressIndexedKey)
+		}
+		return nil
+	}
+	if indexed {
+		return nil
+	}
+	if err := chain.rebuildAddressIndex(); err != nil {
+		return err
+	}
+	return chain.Database.Put(addressIndexedKey, []byte{1})
+}
+
+// deletes every address index entry and indexes the canonical chain
+func (chain *BlockChain) rebuildAddressIndex() error {
+	iter := chain.Database.NewIterator(addressPrefix)
+	batch := chain.Database.NewBatch()
+	for iter.Next() {
+		if err := batch.Delete(append([]byte{}, iter.Key()...)); err != nil {
+			iter.Release()
+			return err
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	var (
+		head   = chain.CurrentHeight()
+		logged = time.Now()
+	)
+	for height := chain.tailHeight(); height <= head; height++ {
+		b := chain.GetBlockByHeight(height)
+		if b == nil {
+			return fmt.Errorf("%w: no canonical block at height %d", ErrChainCorrupt, height)
+		}
+		if err := chain.writeAddressIndex(chain.Database, b); err != nil {
+			return err
+		}
+		if time.Since(logged) > migrationLogInterval {
+			slog.Info("indexing addresses", "height", height, "head", head)
+			logged = time.Now()
+		}
+	}
+	if head > 0 {
+		slog.Info("indexed addresses", "head", head)
+	}
+	return nil
+}
+
+// returns up to limit transactions sent or received by addr, oldest first,
+// skipping the first offset. the address index has to be enabled in the
+// chain options
+func (chain *BlockChain) GetAddressHistory(addr common.Address, offset, limit uint64) ([]*AddressTx, error) {
+	if !chain.Options.IndexAddresses {
+		return nil, ErrAddressIndexDisabled
+	}
+	if indexed, _ := chain.Database.Has(addressIndexedKey); !indexed {
+		return nil, ErrAddressIndexIncomplete
+	}
+
+	iter := nexadb.Table(chain.Database, string(addressIndexPrefix(addr))).NewIterator(nil)
+	defer iter.Release()
+
+	var history []*AddressTx
+	for iter.Next() && uint64(len(history)) < limit {
+		if offset > 0 {
+			offset--
+			continue
+		}
+		key, value := iter.Key(), iter.Value()
+		role := value[common.HashLength]
+		history = append(history, &AddressTx{
+			Height:   binary.BigEndian.Uint64(key[:8]),
+			Index:    binary.BigEndian.Uint64(key[8:16]),
+			TxHash:   common.Hash(value[:common.HashLength]),
+			Sent:     role&addressSent != 0,
+			Received: role&addressReceived != 0,
+		})
+	}
+	return history, iter.Error()
+}
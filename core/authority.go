@@ -0,0 +1,632 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/common/lru"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/leveldb"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+const (
+	// difficulty of a block sealed by the signer whose turn it is
+	diffInTurn uint64 = 2
+	// difficulty of a block sealed by any other signer
+	diffNoTurn uint64 = 1
+
+	// extra delay per signer an out of turn signer waits before sealing
+	// so the in turn signer gets a head start
+	wiggleTime = 500 * time.Millisecond
+
+	// authorization snapshots kept in memory, older ones are rebuilt from
+	// the nearest cached ancestor when needed
+	poaSnapshotCacheSize = 128
+)
+
+var (
+	ErrUnauthorizedSigner = errors.New("block signer is not in the signer list")
+	ErrRecentlySigned     = errors.New("signer has signed too recently")
+	ErrInvalidSeal        = errors.New("block seal signature is invalid")
+	ErrInvalidTurn        = errors.New("block difficulty does not match signer turn")
+	ErrInvalidVote        = errors.New("checkpoint blocks cannot carry votes")
+	ErrBlockTooSoon       = errors.New("block is sealed before the period has passed")
+	ErrInvalidCheckpoint  = errors.New("invalid signer checkpoint")
+)
+
+// a signer's vote to add or remove another signer
+type poaVote struct {
+	Signer  common.Address
+	Address common.Address
+	Add     bool
+}
+
+type poaTally struct {
+	Add   bool
+	Votes int
+}
+
+// the authorization state at a given block
+type poaSnapshot struct {
+	Height  uint64
+	Signers map[common.Address]struct{}
+	Recents map[uint64]common.Address
+	Votes   []*poaVote
+	Tally   map[common.Address]poaTally
+}
+
+func newPoaSnapshot(signers []common.Address) *poaSnapshot {
+	snap := &poaSnapshot{
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Tally:   make(map[common.Address]poaTally),
+	}
+	for _, signer := range signers {
+		snap.Signers[signer] = struct{}{}
+	}
+	return snap
+}
+
+func (s *poaSnapshot) copy() *poaSnapshot {
+	cpy := &poaSnapshot{
+		Height:  s.Height,
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Votes:   make([]*poaVote, len(s.Votes)),
+		Tally:   make(map[common.Address]poaTally),
+	}
+	for signer := range s.Signers {
+		cpy.Signers[signer] = struct{}{}
+	}
+	for height, signer := range s.Recents {
+		cpy.Recents[height] = signer
+	}
+	for addr, tally := range s.Tally {
+		cpy.Tally[addr] = tally
+	}
+	copy(cpy.Votes, s.Votes)
+	return cpy
+}
+
+// returns the signers in address order, which is also the turn order
+func (s *poaSnapshot) signers() []common.Address {
+	signers := make([]common.Address, 0, len(s.Signers))
+	for signer := range s.Signers {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0
+	})
+	return signers
+}
+
+func (s *poaSnapshot) inturn(height uint64, signer common.Address) bool {
+	signers := s.signers()
+	return len(signers) > 0 && signers[height%uint64(len(signers))] == signer
+}
+
+// encodes the snapshot as a signer checkpoint: the height, the signers in
+// address order, the recent signers in height order with the height each
+// signed and the standing votes in the order they were cast. counts are 4
+// big endian bytes, heights 8 and a vote ends in a byte that is 1 to add.
+// the tally is not stored, it follows from the votes
+func (s *poaSnapshot) encode() []byte {
+	enc := binary.BigEndian.AppendUint64(nil, s.Height)
+	signers := s.signers()
+	enc = binary.BigEndian.AppendUint32(enc, uint32(len(signers)))
+	for _, signer := range signers {
+		enc = append(enc, signer.Bytes()...)
+	}
+	heights := make([]uint64, 0, len(s.Recents))
+	for height := range s.Recents {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	enc = binary.BigEndian.AppendUint32(enc, uint32(len(heights)))
+	for _, height := range heights {
+		enc = binary.BigEndian.AppendUint64(enc, height)
+		enc = append(enc, s.Recents[height].Bytes()...)
+	}
+	enc = binary.BigEndian.AppendUint32(enc, uint32(len(s.Votes)))
+	for _, vote := range s.Votes {
+		enc = append(enc, vote.Signer.Bytes()...)
+		enc = append(enc, vote.Address.Bytes()...)
+		add := byte(0)
+		if vote.Add {
+			add = 1
+		}
+		enc = append(enc, add)
+	}
+	return enc
+}
+
+// decodes a signer checkpoint written by encode
+func decodePoaSnapshot(data []byte) (*poaSnapshot, error) {
+	s := newPoaSnapshot(nil)
+	// reads n records of size bytes each, after their 4 byte count
+	records := func(size int) ([]byte, int, error) {
+		if len(data) < 4 {
+			return nil, 0, ErrInvalidCheckpoint
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		if n > (len(data)-4)/size {
+			return nil, 0, ErrInvalidCheckpoint
+		}
+		recs := data[4 : 4+n*size]
+		data = data[4+n*size:]
+		return recs, n, nil
+	}
+	if len(data) < 8 {
+		return nil, ErrInvalidCheckpoint
+	}
+	s.Height, data = binary.BigEndian.Uint64(data), data[8:]
+
+	recs, n, err := records(common.AddressLength)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		s.Signers[common.Address(recs[i*common.AddressLength:])] = struct{}{}
+	}
+	if len(s.Signers) != n || n == 0 {
+		return nil, fmt.Errorf("%w: %d signers, %d distinct", ErrInvalidCheckpoint, n, len(s.Signers))
+	}
+
+	size := 8 + common.AddressLength
+	if recs, n, err = records(size); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		rec := recs[i*size:]
+		s.Recents[binary.BigEndian.Uint64(rec)] = common.Address(rec[8:size])
+	}
+
+	size = 2*common.AddressLength + 1
+	if recs, n, err = records(size); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		rec := recs[i*size:]
+		vote := &poaVote{
+			Signer:  common.Address(rec[:common.AddressLength]),
+			Address: common.Address(rec[common.AddressLength : 2*common.AddressLength]),
+			Add:     rec[size-1] == 1,
+		}
+		tally := s.Tally[vote.Address]
+		if rec[size-1] > 1 || (tally.Votes > 0 && tally.Add != vote.Add) {
+			return nil, fmt.Errorf("%w: vote on %s", ErrInvalidCheckpoint, vote.Address.Hex())
+		}
+		tally.Add = vote.Add
+		tally.Votes++
+		s.Tally[vote.Address] = tally
+		s.Votes = append(s.Votes, vote)
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidCheckpoint)
+	}
+	return s, nil
+}
+
+// a signer may only seal one of any len(signers)/2+1 consecutive blocks
+func (s *poaSnapshot) recentlySigned(height uint64, signer common.Address) bool {
+	limit := uint64(len(s.Signers)/2 + 1)
+	for seen, recent := range s.Recents {
+		if recent == signer && height < seen+limit {
+			return true
+		}
+	}
+	return false
+}
+
+// a vote only counts if it would change something
+func (s *poaSnapshot) validVote(address common.Address, add bool) bool {
+	_, signer := s.Signers[address]
+	return (signer && !add) || (!signer && add)
+}
+
+func (s *poaSnapshot) uncast(address common.Address, add bool) {
+	tally, ok := s.Tally[address]
+	if !ok || tally.Add != add {
+		return
+	}
+	if tally.Votes > 1 {
+		tally.Votes--
+		s.Tally[address] = tally
+	} else {
+		delete(s.Tally, address)
+	}
+}
+
+// moves the snapshot forward by one block
+func (s *poaSnapshot) apply(b *types.Block, epoch uint64) error {
+	if b.Height%epoch == 0 {
+		s.Votes = nil
+		s.Tally = make(map[common.Address]poaTally)
+	}
+	limit := uint64(len(s.Signers)/2 + 1)
+	if b.Height >= limit {
+		delete(s.Recents, b.Height-limit)
+	}
+
+	signer := b.Proposer
+	if _, ok := s.Signers[signer]; !ok {
+		return ErrUnauthorizedSigner
+	}
+	if s.recentlySigned(b.Height, signer) {
+		return ErrRecentlySigned
+	}
+	s.Recents[b.Height] = signer
+
+	if b.Vote != (common.Address{}) {
+		// a signer only has one standing vote per candidate
+		for i, vote := range s.Votes {
+			if vote.Signer == signer && vote.Address == b.Vote {
+				s.uncast(vote.Address, vote.Add)
+				s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+				break
+			}
+		}
+		if s.validVote(b.Vote, b.VoteAdd) {
+			tally := s.Tally[b.Vote]
+			tally.Add = b.VoteAdd
+			tally.Votes++
+			s.Tally[b.Vote] = tally
+			s.Votes = append(s.Votes, &poaVote{Signer: signer, Address: b.Vote, Add: b.VoteAdd})
+		}
+
+		if tally := s.Tally[b.Vote]; tally.Votes > len(s.Signers)/2 {
+			if tally.Add {
+				s.Signers[b.Vote] = struct{}{}
+			} else {
+				delete(s.Signers, b.Vote)
+				for height, recent := range s.Recents {
+					if recent == b.Vote {
+						delete(s.Recents, height)
+					}
+				}
+				// votes from a removed signer no longer count
+				for i := 0; i < len(s.Votes); i++ {
+					if s.Votes[i].Signer == b.Vote {
+						s.uncast(s.Votes[i].Address, s.Votes[i].Add)
+						s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+						i--
+					}
+				}
+			}
+			for i := 0; i < len(s.Votes); i++ {
+				if s.Votes[i].Address == b.Vote {
+					s.Votes = append(s.Votes[:i], s.Votes[i+1:]...)
+					i--
+				}
+			}
+			delete(s.Tally, b.Vote)
+		}
+	}
+	s.Height = b.Height
+	return nil
+}
+
+// ProofOfAuth lets a fixed list of known signers take turns sealing blocks.
+// signers vote others in or out through the Vote fields of the blocks they
+// seal, and a majority of signers is needed for a change to take effect.
+// the authorization state at every epoch block is stored as a checkpoint,
+// so working it out never goes further back than the last epoch.
+type ProofOfAuth struct {
+	config *params.PoaConfig
+	signer *wallet.Wallet
+	db     nexadb.KeyValueStore // holds the checkpoints, nil keeps none
+
+	lock      sync.Mutex
+	snapshots *lru.Cache[common.Hash, *poaSnapshot]
+	proposals map[common.Address]bool
+}
+
+func NewProofOfAuth(config *params.PoaConfig, db nexadb.KeyValueStore) *ProofOfAuth {
+	if config == nil {
+		config = params.DefaultPoaConfig
+	}
+	// defaults are filled into a copy, the caller's config is left alone
+	cfg := *config
+	cfg.Signers = append([]common.Address(nil), config.Signers...)
+	if cfg.Epoch == 0 {
+		cfg.Epoch = params.DefaultPoaConfig.Epoch
+	}
+	return &ProofOfAuth{
+		config:    &cfg,
+		db:        db,
+		snapshots: lru.New[common.Hash, *poaSnapshot](poaSnapshotCacheSize),
+		proposals: make(map[common.Address]bool),
+	}
+}
+
+// sets the wallet used to seal local blocks
+func (poa *ProofOfAuth) Authorize(w *wallet.Wallet) {
+	poa.signer = w
+}
+
+// queues a vote to add (add = true) or remove a signer in locally sealed blocks
+func (poa *ProofOfAuth) Propose(address common.Address, add bool) {
+	poa.lock.Lock()
+	defer poa.lock.Unlock()
+	poa.proposals[address] = add
+}
+
+// drops a queued vote
+func (poa *ProofOfAuth) Discard(address common.Address) {
+	poa.lock.Lock()
+	defer poa.lock.Unlock()
+	delete(poa.proposals, address)
+}
+
+// returns the signers allowed to seal the block after the given one
+func (poa *ProofOfAuth) Signers(chain consensus.ChainReader, hash common.Hash) ([]common.Address, error) {
+	snap, err := poa.snapshot(chain, hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.signers(), nil
+}
+
+// returns the authorization state after the block with the given hash
+func (poa *ProofOfAuth) snapshot(chain consensus.ChainReader, hash common.Hash) (*poaSnapshot, error) {
+	poa.lock.Lock()
+	defer poa.lock.Unlock()
+
+	var pending []*types.Block
+	snap := (*poaSnapshot)(nil)
+	for {
+		if cached, ok := poa.snapshots.Get(hash); ok {
+			snap = cached
+			break
+		}
+		if hash == GenesisParentHash {
+			snap = newPoaSnapshot(poa.config.Signers)
+			poa.snapshots.Add(hash, snap)
+			break
+		}
+		if stored := poa.loadCheckpoint(hash); stored != nil {
+			snap = stored
+			poa.snapshots.Add(hash, snap)
+			break
+		}
+		b := chain.GetBlock(hash)
+		if b == nil {
+			return nil, ErrUnknownParent
+		}
+		pending = append(pending, b)
+		hash = b.ParentHash
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		next := snap.copy()
+		if err := next.apply(pending[i], poa.config.Epoch); err != nil {
+			return nil, err
+		}
+		poa.snapshots.Add(pending[i].Hash, next)
+		if pending[i].Height%poa.config.Epoch == 0 {
+			poa.storeCheckpoint(pending[i].Hash, next)
+		}
+		snap = next
+	}
+	return snap, nil
+}
+
+// reads the checkpoint stored for the block with the given hash, nil if
+// there is none
+func (poa *ProofOfAuth) loadCheckpoint(hash common.Hash) *poaSnapshot {
+	if poa.db == nil {
+		return nil
+	}
+	data, err := poa.db.Get(poaCheckpointKey(hash))
+	if err != nil {
+		return nil
+	}
+	snap, err := decodePoaSnapshot(data)
+	if err != nil {
+		slog.Error("ignoring corrupt signer checkpoint", "hash", hash.Hex(), "err", err)
+		return nil
+	}
+	return snap
+}
+
+// stores the checkpoint after the block with the given hash. a checkpoint
+// that is not written only costs a longer walk, so failures are logged
+func (poa *ProofOfAuth) storeCheckpoint(hash common.Hash, snap *poaSnapshot) {
+	if poa.db == nil {
+		return
+	}
+	if err := poa.db.Put(poaCheckpointKey(hash), snap.encode()); err != nil && !errors.Is(err, leveldb.ErrReadOnly) {
+		slog.Warn("failed to store signer checkpoint", "height", snap.Height, "err", err)
+	}
+}
+
+// returns the checkpoint after the block with the given hash, for a chain
+// that starts at that block
+func (poa *ProofOfAuth) exportCheckpoint(chain consensus.ChainReader, hash common.Hash) ([]byte, error) {
+	snap, err := poa.snapshot(chain, hash)
+	if err != nil {
+		return nil, err
+	}
+	return snap.encode(), nil
+}
+
+// takes the checkpoint after the block b as the authorization state a
+// chain started at b builds on, nothing before b is needed then
+func (poa *ProofOfAuth) importCheckpoint(b *types.Block, data []byte) error {
+	snap, err := decodePoaSnapshot(data)
+	if err != nil {
+		return err
+	}
+	if snap.Height != b.Height {
+		return fmt.Errorf("%w: taken at height %d, not %d", ErrInvalidCheckpoint, snap.Height, b.Height)
+	}
+	if poa.db == nil {
+		return fmt.Errorf("%w: no database to store it in", ErrInvalidCheckpoint)
+	}
+	if err := poa.db.Put(poaCheckpointKey(b.Hash), data); err != nil {
+		return err
+	}
+	poa.lock.Lock()
+	poa.snapshots.Add(b.Hash, snap)
+	poa.lock.Unlock()
+	return nil
+}
+
+func (poa *ProofOfAuth) Author(b *types.Block) (common.Address, error) {
+	return b.Proposer, nil
+}
+
+func (poa *ProofOfAuth) VerifyHeader(chain consensus.ChainReader, b *types.Block) error {
+	if b.Time > time.Now().Add(allowedFutureBlockTime).Unix() {
+		return ErrFutureBlock
+	}
+	if parent := chain.GetBlock(b.ParentHash); parent != nil && uint64(b.Time) < uint64(parent.Time)+poa.config.Period {
+		return ErrBlockTooSoon
+	}
+	if b.Height%poa.config.Epoch == 0 && b.Vote != (common.Address{}) {
+		return ErrInvalidVote
+	}
+	if wallet.PubKeyToAddress(b.PublicKey) != b.Proposer ||
+		!wallet.VerifySignature(b.PublicKey, b.SealHash(), b.Signature) {
+		return ErrInvalidSeal
+	}
+
+	snap, err := poa.snapshot(chain, b.ParentHash)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[b.Proposer]; !ok {
+		return ErrUnauthorizedSigner
+	}
+	if snap.recentlySigned(b.Height, b.Proposer) {
+		return ErrRecentlySigned
+	}
+	expected := diffNoTurn
+	if snap.inturn(b.Height, b.Proposer) {
+		expected = diffInTurn
+	}
+	if b.Difficulty != expected {
+		return ErrInvalidTurn
+	}
+	return nil
+}
+
+func (poa *ProofOfAuth) Prepare(chain consensus.ChainReader, b *types.Block) error {
+	if poa.signer == nil {
+		return ErrNoSigner
+	}
+	snap, err := poa.snapshot(chain, b.ParentHash)
+	if err != nil {
+		return err
+	}
+
+	b.Proposer = poa.signer.Address
+	b.PublicKey = poa.signer.PublicKeyBytes()
+	b.Vote = common.Address{}
+	b.VoteAdd = false
+	if b.Height%poa.config.Epoch != 0 {
+		poa.lock.Lock()
+		var candidates []common.Address
+		for addr, add := range poa.proposals {
+			if snap.validVote(addr, add) {
+				candidates = append(candidates, addr)
+			}
+		}
+		if len(candidates) > 0 {
+			b.Vote = candidates[rand.Intn(len(candidates))]
+			b.VoteAdd = poa.proposals[b.Vote]
+		}
+		poa.lock.Unlock()
+	}
+
+	b.Difficulty = diffNoTurn
+	if snap.inturn(b.Height, b.Proposer) {
+		b.Difficulty = diffInTurn
+	}
+
+	if parent := chain.GetBlock(b.ParentHash); parent != nil {
+		if earliest := parent.Time + int64(poa.config.Period); b.Time < earliest {
+			b.Time = earliest
+		}
+	}
+	return nil
+}
+
+// caches the snapshot after b so the next block does not rebuild it
+func (poa *ProofOfAuth) Finalize(chain consensus.ChainReader, b *types.Block) error {
+	_, err := poa.snapshot(chain, b.Hash)
+	return err
+}
+
+// waits for the block's time, plus a random delay if it is not our turn,
+// then signs it with the authorized wallet
+func (poa *ProofOfAuth) Seal(chain consensus.ChainReader, b *types.Block) error {
+	if poa.signer == nil {
+		return ErrNoSigner
+	}
+	snap, err := poa.snapshot(chain, b.ParentHash)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[poa.signer.Address]; !ok {
+		return ErrUnauthorizedSigner
+	}
+	if snap.recentlySigned(b.Height, poa.signer.Address) {
+		return ErrRecentlySigned
+	}
+
+	delay := time.Until(time.Unix(b.Time, 0))
+	if b.Difficulty == diffNoTurn {
+		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
+		delay += time.Duration(rand.Int63n(int64(wiggle)))
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	sig, err := poa.signer.Sign(b.SealHash())
+	if err != nil {
+		return err
+	}
+	b.Signature = sig
+	b.Hash = b.ComputeHash()
+	return nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// opens a proof of authority chain in dir with short epochs that w alone
+// signs
+func newTestAuthChain(t *testing.T, dir string, w *wallet.Wallet) *BlockChain {
+	t.Helper()
+	config := *params.DefaultChainConfig
+	config.Consensus = params.ProofOfAuth
+	config.Poa = &params.PoaConfig{Signers: []common.Address{w.Address}, Period: 1, Epoch: 4}
+	chain, err := NewChainWithConfig(&config, &Options{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	chain.Engine.(*ProofOfAuth).Authorize(w)
+	return chain
+}
+
+func TestPoaFutureBlock(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestAuthChain(t, "db", w)
+	b, err := chain.BuildBlock(time.Now().Add(time.Hour).Unix(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Engine.VerifyHeader(chain, b); !errors.Is(err, ErrFutureBlock) {
+		t.Fatalf("block an hour ahead: %v, want %v", err, ErrFutureBlock)
+	}
+}
+
+// the signer checkpoint of every epoch block is stored, and a chain started
+// from a snapshot verifies the blocks after it from the checkpoint the
+// snapshot carries
+func TestPoaCheckpointSnapshot(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestAuthChain(t, "db", w)
+	base := time.Now().Unix() - 1000
+	for i := int64(0); i < 6; i++ {
+		insertTestBlockAt(t, chain, base+10*i)
+	}
+	if ok, _ := chain.Database.Has(poaCheckpointKey(chain.GetBlockByHeight(4).Hash)); !ok {
+		t.Fatal("no signer checkpoint stored for the epoch block")
+	}
+	if ok, _ := chain.Database.Has(poaCheckpointKey(chain.GetBlockByHeight(5).Hash)); ok {
+		t.Fatal("signer checkpoint stored for a block inside the epoch")
+	}
+
+	var snap bytes.Buffer
+	if err := chain.ExportSnapshot(&snap); err != nil {
+		t.Fatal(err)
+	}
+	head := chain.CurrentBlock()
+	other := newTestAuthChain(t, "other", w)
+	if _, err := other.ImportSnapshot(&snap, head.Hash); err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(6); i < 10; i++ {
+		b := insertTestBlockAt(t, chain, base+10*i)
+		if err := other.Insert(b); err != nil {
+			t.Fatalf("block %d on the chain started from the snapshot: %v", b.Height, err)
+		}
+	}
+	if other.CurrentBlock().Hash != chain.CurrentBlock().Hash {
+		t.Fatal("the chain started from the snapshot did not follow")
+	}
+}
@@ -0,0 +1,254 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/freezer"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/leveldb"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// writes a copy of the chain as it is now into dir while the chain stays
+// in use. dir is laid out like a chain database, with the freezer in its
+// "ancient" folder, so a node can be started on it. the copy is then opened
+// and has to pass the sanity check at the same head, otherwise it is removed
+func (chain *BlockChain) Backup(dir string) error {
+	if empty, err := isEmptyDir(dir); err != nil {
+		return err
+	} else if !empty {
+		return fmt.Errorf("%w: %s", leveldb.ErrCheckpointExists, dir)
+	}
+	opts := &Options{DataDir: dir}
+	head, err := chain.copyTo(opts.dataDir(), opts.ancientDir())
+	if err == nil {
+		opts.ReadOnly = true
+		err = checkBackup(chain.Config, opts, head)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// replaces the chain database and freezer named by opts with the backup in
+// dir. the backup has to pass the sanity check first. a database already
+// there is moved aside to a folder named after it and the time, so nothing
+// is lost, and moved back if the restore fails. no node may have the
+// database open while it is restored
+func Restore(config *params.ChainConfig, dir string, opts *Options) error {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	backupOpts := &Options{DataDir: dir, ReadOnly: true}
+	if err := checkBackup(config, backupOpts, nil); err != nil {
+		return err
+	}
+
+	dataDir, ancientDir := opts.dataDir(), opts.ancientDir()
+	empty, err := isEmptyDir(dataDir)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		// leveldb refuses to open a database another process holds
+		db, err := leveldb.New(dataDir)
+		if err != nil {
+			return fmt.Errorf("chain database is in use: %w", err)
+		}
+		db.Close()
+	}
+	suffix := fmt.Sprintf(".old-%d", time.Now().Unix())
+	moved, err := moveAside([]string{dataDir, ancientDir}, suffix)
+	if err != nil {
+		moveBack(moved, suffix)
+		return err
+	}
+	err = restoreInto(config, backupOpts, &Options{DataDir: dataDir, AncientDir: opts.AncientDir, ReadOnly: true})
+	if err != nil {
+		// whatever was there before is moved aside, so everything in the
+		// target directories now was written by the restore
+		os.RemoveAll(dataDir)
+		os.RemoveAll(ancientDir)
+		moveBack(moved, suffix)
+		return err
+	}
+	return nil
+}
+
+// copies the backup described by backupOpts into the directories of
+// target and checks the copy
+func restoreInto(config *params.ChainConfig, backupOpts, target *Options) error {
+	backup, err := NewChainWithConfig(config, backupOpts)
+	if err != nil {
+		return err
+	}
+	defer backup.Close()
+
+	head, err := backup.copyTo(target.dataDir(), target.ancientDir())
+	if err != nil {
+		return err
+	}
+	return checkBackup(config, target, head)
+}
+
+// renames every non-empty path to path+suffix and returns the ones it
+// moved, also when it fails part way
+func moveAside(paths []string, suffix string) ([]string, error) {
+	var moved []string
+	for _, path := range paths {
+		empty, err := isEmptyDir(path)
+		if err != nil {
+			return moved, err
+		}
+		if empty {
+			continue
+		}
+		if err := os.Rename(path, path+suffix); err != nil {
+			return moved, err
+		}
+		moved = append(moved, path)
+		slog.Info("moved the current chain data aside", "dir", path+suffix)
+	}
+	return moved, nil
+}
+
+// puts the paths moveAside moved back where they were
+func moveBack(moved []string, suffix string) {
+	for i := len(moved) - 1; i >= 0; i-- {
+		path := moved[i]
+		if err := os.Rename(path+suffix, path); err != nil {
+			slog.Error("failed to move chain data back", "dir", path+suffix, "err", err)
+			continue
+		}
+		slog.Info("moved the chain data back", "dir", path)
+	}
+}
+
+// copies the database and the freezer as they are now into the given
+// directories and returns the head of the copy. the database is read from
+// a snapshot taken together with the freezer length, so the two match
+func (chain *BlockChain) copyTo(dataDir, ancientDir string) (*types.Block, error) {
+	if empty, err := isEmptyDir(ancientDir); err != nil {
+		return nil, err
+	} else if !empty {
+		return nil, fmt.Errorf("%w: %s", leveldb.ErrCheckpointExists, ancientDir)
+	}
+
+	chain.chainmu.Lock()
+	snap, err := chain.Database.NewSnapshot()
+	frozen := chain.FrozenHeight()
+	head := chain.head.Load()
+	chain.chainmu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Release()
+
+	start := time.Now()
+	if err := leveldb.Checkpoint(snap, dataDir); err != nil {
+		return nil, err
+	}
+	if err := chain.copyFreezer(ancientDir, frozen); err != nil {
+		os.RemoveAll(ancientDir)
+		return nil, err
+	}
+	height := uint64(0)
+	if head != nil {
+		height = head.Height
+	}
+	slog.Info("copied chain", "dir", dataDir, "head", height, "frozen", frozen, "elapsed", time.Since(start))
+	return head, nil
+}
+
+// copies the first items items of the freezer into a new freezer in dir
+func (chain *BlockChain) copyFreezer(dir string, items uint64) error {
+	out, err := freezer.New(dir, freezerTables)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for n := uint64(0); n < items; n++ {
+		item := make(map[string][]byte, len(freezerTables))
+		for _, name := range freezerTables {
+			if item[name], err = chain.Ancients.Retrieve(name, n); err != nil {
+				return err
+			}
+		}
+		if err := out.Append(n, item); err != nil {
+			return err
+		}
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// opens the chain described by opts read-only and runs the sanity check on
+// it. if head is given the chain also has to end there
+func checkBackup(config *params.ChainConfig, opts *Options, head *types.Block) error {
+	backup, err := NewChainWithConfig(config, opts)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupInvalid, err)
+	}
+	defer backup.Close()
+
+	last := backup.CurrentBlock()
+	switch {
+	case last == nil:
+		return fmt.Errorf("%w: it holds no blocks", ErrBackupInvalid)
+	case !backup.SanityCheck():
+		return fmt.Errorf("%w: sanity check failed", ErrBackupInvalid)
+	case head != nil && last.Hash != head.Hash:
+		return fmt.Errorf("%w: head is block %d, expected %d", ErrBackupInvalid, last.Height, head.Height)
+	}
+	slog.Info("backup passed the sanity check", "dir", opts.dataDir(), "head", last.Height)
+	return nil
+}
+
+// reports whether dir is missing or an empty directory. anything else,
+// including a path that cannot be read, is not safe to write over
+func isEmptyDir(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 0, nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+)
+
+// a backup taken while the chain runs restores into a chain at the same
+// head, and a restore never writes over a database a node has open
+func TestBackupRestore(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+	insertTestBlock(t, chain, signedTestTx(t, w, 0, common.Address{1}, 5))
+	head := chain.CurrentBlock()
+
+	if err := chain.Backup("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Backup("backup"); err == nil {
+		t.Fatal("backup into a directory that is not empty succeeded")
+	}
+	insertTestBlock(t, chain)
+
+	if err := Restore(chain.Config, "backup", &Options{DataDir: "db"}); err == nil {
+		t.Fatal("restore over a database in use succeeded")
+	}
+	if err := Restore(chain.Config, "backup", &Options{DataDir: "restored"}); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewChainWithConfig(chain.Config, &Options{DataDir: "restored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if got := restored.CurrentBlock(); got == nil || got.Hash != head.Hash {
+		t.Fatalf("restored head %v, want block %d", got, head.Height)
+	}
+	statedb, err := restored.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statedb.GetBalance(common.Address{1}) != 5 {
+		t.Fatal("the restored state does not hold the transfer")
+	}
+	if _, err := restored.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a read-only chain sees the database as it was when opened, next to the
+// node that keeps writing it, and refuses to change it
+func TestReadOnlyChain(t *testing.T) {
+	setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+	head := chain.CurrentBlock()
+
+	view, err := NewChainWithConfig(chain.Config, &Options{DataDir: "db", ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+	next := insertTestBlock(t, chain)
+
+	if got := view.CurrentBlock(); got == nil || got.Hash != head.Hash {
+		t.Fatalf("read-only head %v, want block %d", got, head.Height)
+	}
+	if err := view.Insert(next); !errors.Is(err, ErrReadOnlyDatabase) {
+		t.Fatalf("insert into a read-only chain: %v, want %v", err, ErrReadOnlyDatabase)
+	}
+	if err := view.SetHead(0); !errors.Is(err, ErrReadOnlyDatabase) {
+		t.Fatalf("rewinding a read-only chain: %v, want %v", err, ErrReadOnlyDatabase)
+	}
+}
@@ -27,13 +27,25 @@
 package core
 
 import (
+	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"math/big"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
 
 	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/common/lru"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/snapshot"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
 	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/freezer"
 	"github.com/PulseCoinOrg/nexacoin/nexadb/leveldb"
+	"github.com/PulseCoinOrg/nexacoin/params"
 )
 
 var (
@@ -41,172 +53,674 @@ var (
 	ChainDiskPath = "./chaindb-output"
 )
 
-// removes the chain disk leveldb folder from systsem
+// removes the chain disk leveldb folder and everything in it from systsem
 func DeleteDiskFolder() error {
-	err := os.Remove(ChainDiskPath)
+	err := os.RemoveAll(ChainDiskPath)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// BlockChain is safe for concurrent use. inserts, reorgs and finalization
+// are serialised by a single writer lock while the head and finalized block
+// can be read at any time without taking it
 type BlockChain struct {
-	Database     *leveldb.Database
-	Height       uint64
-	Sane         bool
-	LastBlock    *types.Block
-	BlocksMemory map[common.Hash]*types.Block
-	Validators   *ValidatorPool
+	Config     *params.ChainConfig
+	Options    *Options
+	Database   *leveldb.Database
+	Ancients   *freezer.Freezer // finalized blocks and receipts, by height
+	Validators *ValidatorPool
+	Engine     consensus.Engine
+
+	chainmu   sync.Mutex // held by everything that writes to the chain
+	head      atomic.Pointer[types.Block]
+	finalized atomic.Pointer[types.Block]
+	sane      atomic.Bool
+
+	headerCache   *lru.Cache[common.Hash, *types.Block] // blocks without their transactions
+	bodyCache     *lru.Cache[common.Hash, []*types.Transaction]
+	receiptsCache *lru.Cache[common.Hash, *types.BlockReceipts]
+	stateCache    *lru.Cache[common.Hash, *state.StateDB] // never modified, only copied
+	snaps         *snapshot.Tree                          // flat accounts for fast reads
+
+	finality    *finalityGadget
+	pruner      *statePruner
+	genesisRoot common.Hash
+	feeds       chainFeeds
 }
 
 func NewChain() (*BlockChain, error) {
-	db, err := leveldb.New(ChainDiskPath)
+	return NewChainWithConfig(params.DefaultChainConfig, DefaultOptions)
+}
+
+func NewChainWithConfig(config *params.ChainConfig, opts *Options) (*BlockChain, error) {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	open := leveldb.New
+	if opts.ReadOnly {
+		open = leveldb.NewReadOnly
+	}
+	db, err := open(opts.dataDir())
+	if err != nil {
+		return nil, err
+	}
 	if db == nil {
 		return nil, ErrChainDatabaseClosed
 	}
+	chain, err := setupChain(config, opts, db)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
-	return &BlockChain{
-		Database:     db,
-		BlocksMemory: make(map[common.Hash]*types.Block),
-		Validators:   NewValidatorPool(),
-	}, nil
+	return chain, nil
 }
 
-// returns the first element in the chain from leveldb
-func (chain *BlockChain) First() (*types.Block, error) {
-	_, value, err := chain.Database.First()
+// builds the chain on an open database. on error the caller still owns db
+// and has to close it, anything opened here is closed again
+func setupChain(config *params.ChainConfig, opts *Options, db *leveldb.Database) (*BlockChain, error) {
+	version, err := checkSchemaVersion(db, opts.ReadOnly)
 	if err != nil {
 		return nil, err
 	}
-	block := types.DecodeBlockBytesStream(value)
+	validators := NewValidatorPoolWithEpoch(config.EpochLength)
+	engine, err := CreateConsensusEngine(config, validators, db)
+	if err != nil {
+		return nil, err
+	}
+	chain := &BlockChain{
+		Config:     config,
+		Options:    opts,
+		Database:   db,
+		Validators: validators,
+		Engine:     engine,
+		finality:   newFinalityGadget(),
+
+		headerCache:   lru.New[common.Hash, *types.Block](cacheSize(opts.HeaderCacheSize, DefaultHeaderCacheSize)),
+		bodyCache:     lru.New[common.Hash, []*types.Transaction](cacheSize(opts.BodyCacheSize, DefaultBodyCacheSize)),
+		receiptsCache: lru.New[common.Hash, *types.BlockReceipts](cacheSize(opts.ReceiptsCacheSize, DefaultReceiptsCacheSize)),
+		stateCache:    lru.New[common.Hash, *state.StateDB](cacheSize(opts.StateCacheSize, DefaultStateCacheSize)),
+	}
+	if err := chain.openFreezer(); err != nil {
+		return nil, err
+	}
+	if err := chain.loadChain(version); err != nil {
+		chain.Ancients.Close()
+		return nil, err
+	}
+	return chain, nil
+}
+
+// brings the database up to date and loads the head, once the freezer is open
+func (chain *BlockChain) loadChain(version uint64) error {
+	if err := chain.setupGenesisState(); err != nil {
+		return err
+	}
+	if version == 0 && !chain.Options.ReadOnly {
+		if err := chain.convertBaseline(); err != nil {
+			return err
+		}
+	}
+	chain.loadLastState()
+	if chain.Options.ReadOnly {
+		// the node may have frozen more blocks after the database view was
+		// taken, those are not part of the chain seen here
+		if err := chain.Ancients.Truncate(chain.CurrentHeight()); err != nil {
+			return err
+		}
+	}
+	if err := chain.migrate(version); err != nil {
+		return err
+	}
+	if err := chain.setupGCMode(); err != nil {
+		return err
+	}
+	if err := chain.resumeOrphanSweep(); err != nil {
+		return err
+	}
+	if err := chain.setupAddressIndex(); err != nil {
+		return err
+	}
+	chain.setupSnapshot()
+	return nil
+}
+
+// flattens the state snapshot to disk and closes the freezer and the
+// database, the chain cannot be used afterwards
+func (chain *BlockChain) Close() error {
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	chain.capSnapshot(0)
+	if chain.pruner != nil {
+		chain.pruner.wg.Wait()
+	}
+
+	if err := chain.Ancients.Close(); err != nil {
+		return err
+	}
+	return chain.Database.Close()
+}
+
+// writes the genesis allocation and stakes, which is the state the first
+// block builds on
+func (chain *BlockChain) setupGenesisState() error {
+	statedb, err := state.New(state.EmptyRoot, chain.Database)
+	if err != nil {
+		return err
+	}
+	for addr, balance := range chain.Config.Alloc {
+		statedb.Mint(addr, balance)
+	}
+	// genesis stakes are created as coins that are locked right away
+	for _, v := range chain.Config.Validators {
+		statedb.Mint(v.Address, v.Stake)
+		if err := statedb.SubBalance(v.Address, v.Stake); err != nil {
+			return err
+		}
+		statedb.SetStake(v.Address, state.Stake{Active: v.Stake, Commit: v.RandaoCommit})
+	}
+	if chain.Options.ReadOnly {
+		chain.genesisRoot = statedb.IntermediateRoot()
+		return nil
+	}
+	root, err := statedb.Commit()
+	if err != nil {
+		return err
+	}
+	chain.genesisRoot = root
+	return nil
+}
+
+// restores the head and finalized block pointers from leveldb, if there are any
+func (chain *BlockChain) loadLastState() {
+	if hash, err := chain.Database.Get(headBlockKey); err == nil {
+		if head := chain.GetBlock(common.Hash(hash)); head != nil {
+			chain.head.Store(head)
+		}
+	}
+	if hash, err := chain.Database.Get(finalizedBlockKey); err == nil {
+		if finalized := chain.GetBlock(common.Hash(hash)); finalized != nil {
+			chain.finalized.Store(finalized)
+		}
+	}
+}
+
+// returns the head of the canonical chain, nil if the chain is empty
+func (chain *BlockChain) CurrentBlock() *types.Block {
+	return chain.head.Load()
+}
+
+// returns the height of the canonical head, zero if the chain is empty
+func (chain *BlockChain) CurrentHeight() uint64 {
+	if head := chain.head.Load(); head != nil {
+		return head.Height
+	}
+	return 0
+}
+
+// returns the latest finalized block, nil if nothing is finalized yet
+func (chain *BlockChain) FinalizedBlock() *types.Block {
+	return chain.finalized.Load()
+}
+
+// reports the result of the last SanityCheck
+func (chain *BlockChain) Sane() bool {
+	return chain.sane.Load()
+}
+
+// returns the height of the canonical head.
+//
+// Deprecated: the Height field was replaced by CurrentHeight, which reads
+// the head without racing inserts
+func (chain *BlockChain) Height() uint64 {
+	return chain.CurrentHeight()
+}
+
+// returns the head of the canonical chain, nil if the chain is empty.
+//
+// Deprecated: the LastBlock field was replaced by CurrentBlock, which reads
+// the head without racing inserts
+func (chain *BlockChain) LastBlock() *types.Block {
+	return chain.CurrentBlock()
+}
+
+// retrieves a block by hash from the caches, falling back to leveldb
+func (chain *BlockChain) GetBlock(hash common.Hash) *types.Block {
+	header, ok := chain.headerCache.Get(hash)
+	if ok {
+		if txs, ok := chain.bodyCache.Get(hash); ok {
+			block := header.CopyHeader()
+			block.Transactions = types.CopyTxs(txs)
+			return block
+		}
+	}
+	block := chain.readBlock(hash)
+	if block == nil {
+		return nil
+	}
+	chain.cacheBlock(block)
+	return block
+}
+
+// retrieves a block by hash without its transactions
+func (chain *BlockChain) GetHeader(hash common.Hash) *types.Block {
+	if header, ok := chain.headerCache.Get(hash); ok {
+		return header.CopyHeader()
+	}
+	block := chain.readBlock(hash)
+	if block == nil {
+		return nil
+	}
+	chain.cacheBlock(block)
+	block.Transactions = nil
+	return block
+}
+
+// decodes a block straight from leveldb, or from the freezer once it was
+// moved there
+func (chain *BlockChain) readBlock(hash common.Hash) *types.Block {
+	value, err := chain.Database.Get(blockKey(hash))
+	if err != nil {
+		return chain.readAncientBlock(hash)
+	}
+	return types.DecodeBlockBytesStream(value)
+}
+
+// splits a block into its header and body and caches copies of both, so
+// callers are free to modify what they were handed
+func (chain *BlockChain) cacheBlock(b *types.Block) {
+	chain.headerCache.Add(b.Hash, b.CopyHeader())
+	chain.bodyCache.Add(b.Hash, types.CopyTxs(b.Transactions))
+}
+
+// hit and miss counters of the chain's caches
+type CacheStats struct {
+	Headers  lru.Stats
+	Bodies   lru.Stats
+	Receipts lru.Stats
+	States   lru.Stats
+}
+
+// returns the counters of every chain cache
+func (chain *BlockChain) CacheStats() CacheStats {
+	return CacheStats{
+		Headers:  chain.headerCache.Stats(),
+		Bodies:   chain.bodyCache.Stats(),
+		Receipts: chain.receiptsCache.Stats(),
+		States:   chain.stateCache.Stats(),
+	}
+}
+
+// retrieves the canonical block at the given height
+func (chain *BlockChain) GetBlockByHeight(height uint64) *types.Block {
+	hash, err := chain.Database.Get(canonicalKey(height))
+	if err != nil {
+		return nil
+	}
+	return chain.GetBlock(common.Hash(hash))
+}
+
+// retrieves the canonical block at the given height without its transactions
+func (chain *BlockChain) GetHeaderByHeight(height uint64) *types.Block {
+	hash, err := chain.Database.Get(canonicalKey(height))
+	if err != nil {
+		return nil
+	}
+	return chain.GetHeader(common.Hash(hash))
+}
+
+// returns the first element in the chain from leveldb
+func (chain *BlockChain) First() (*types.Block, error) {
+	iter := chain.Database.NewIterator(canonicalPrefix)
+	defer iter.Release()
+
+	if !iter.Next() {
+		return nil, leveldb.ErrNotFound
+	}
+	block := chain.GetBlock(common.Hash(iter.Value()))
+	if block == nil {
+		return nil, leveldb.ErrNotFound
+	}
 	return block, nil
 }
 
-// returns the last element in the chain from leveldb
+// returns the last element in the chain
 func (chain *BlockChain) Last() (*types.Block, error) {
-	_, value, err := chain.Database.Last()
-	if err != nil {
-		return nil, err
+	block := chain.head.Load()
+	if block == nil {
+		return nil, leveldb.ErrNotFound
 	}
-	block := types.DecodeBlockBytesStream(value)
-	chain.LastBlock = block
 	return block, nil
 }
 
+// returns the second to last element in the chain from leveldb
 func (chain *BlockChain) Previous() (*types.Block, error) {
-	_, value, err := chain.Database.Previous()
+	head, err := chain.Last()
 	if err != nil {
 		return nil, err
 	}
-	block := types.DecodeBlockBytesStream(value)
-	chain.LastBlock = block
-	return block, nil
+	parent := chain.GetBlock(head.ParentHash)
+	if parent == nil {
+		return nil, fmt.Errorf("only one block in the chain")
+	}
+	return parent, nil
 }
 
-// returns the second to last element in the chain from leveldb
+// verifies a block and stores it. the block becomes the new head if it extends
+// the current head or belongs to a heavier branch that keeps every finalized
+// block. the block, its receipts and total difficulty, the canonical indexes
+// and the head are written in one batch, so a crash leaves either all of
+// them or none
 func (chain *BlockChain) Insert(b *types.Block) error {
-	if err := chain.Database.Put(b.Hash.Bytes(), b.BytesStream()); err != nil {
+	if chain.headerCache == nil {
 		return ErrBlockChainInsertFailed
 	}
-	if chain.BlocksMemory == nil {
+	if chain.Options.ReadOnly {
+		return ErrReadOnlyDatabase
+	}
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	if b.ComputeHash() != b.Hash {
+		return ErrBlockInvalidHash
+	}
+	if err := chain.verifyAncestry(b); err != nil {
+		return err
+	}
+	if err := chain.Engine.VerifyHeader(chain, b); err != nil {
+		return err
+	}
+
+	batch := chain.Database.NewBatch()
+	receipts, err := chain.processBlock(batch, b)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(blockKey(b.Hash), b.BytesStream()); err != nil {
+		return ErrBlockChainInsertFailed
+	}
+	td, err := chain.writeTd(batch, b)
+	if err != nil {
+		return err
+	}
+	change, err := chain.updateHead(batch, b, td)
+	if err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
 		return ErrBlockChainInsertFailed
 	}
-	chain.BlocksMemory[b.Hash] = b
+
+	chain.cacheBlock(b)
+	chain.receiptsCache.Add(b.Hash, receipts.Copy())
+	if err := chain.Engine.Finalize(chain, b); err != nil {
+		return err
+	}
+	if err := chain.moveHead(b, change); err != nil {
+		return err
+	}
+	return chain.maybePrune(b)
+}
+
+// checks the block sits one above its parent and does not fork away from
+// the finalized block
+func (chain *BlockChain) verifyAncestry(b *types.Block) error {
+	parentHeight := uint64(0)
+	if b.ParentHash != GenesisParentHash {
+		parent := chain.GetBlock(b.ParentHash)
+		if parent == nil {
+			return ErrUnknownParent
+		}
+		parentHeight = parent.Height
+	}
+	if b.Height != parentHeight+1 {
+		return ErrBlockInvalidHeight
+	}
+
+	finalized := chain.finalized.Load()
+	if finalized == nil {
+		return nil
+	}
+	if b.Height <= finalized.Height {
+		return ErrBlockBelowFinalized
+	}
+	current := b
+	for current.Height > finalized.Height+1 {
+		current = chain.GetBlock(current.ParentHash)
+		if current == nil {
+			return ErrUnknownParent
+		}
+	}
+	if current.ParentHash != finalized.Hash {
+		return ErrBlockBelowFinalized
+	}
 	return nil
 }
 
-// this is equivilent to a BlockByHash function
-// retrieves a block by a given hash string
-func (chain *BlockChain) LocateBlock(hash string) *types.Block {
-	for _, block := range chain.BlocksMemory {
-		if block.Hash.Hex() == hash {
-			return block
+// the blocks a new block took out of and put into the canonical chain, each
+// from the top down. a side block changes nothing and has no headChange
+type headChange struct {
+	dropped []*types.Block
+	added   []*types.Block
+}
+
+// applies the fork choice rule to b, whose total difficulty is td: the
+// heaviest branch wins, ties keep the current head. the canonical indexes
+// and the head pointer are written to db and the change is returned for
+// moveHead once db is written. the caller must hold chainmu
+func (chain *BlockChain) updateHead(db nexadb.KeyValueWriter, b *types.Block, td *big.Int) (*headChange, error) {
+	var change *headChange
+	head := chain.head.Load()
+	switch {
+	case head == nil || b.ParentHash == head.Hash:
+		if err := chain.writeCanonical(db, b); err != nil {
+			return nil, err
+		}
+		change = &headChange{added: []*types.Block{b}}
+	case td.Cmp(chain.GetTd(head.Hash)) > 0:
+		var err error
+		if change, err = chain.reorg(db, head, b); err != nil {
+			return nil, err
 		}
+	default:
+		// side block, kept around in case its branch overtakes the head
+		return nil, nil
+	}
+
+	if err := db.Put(headBlockKey, b.Hash.Bytes()); err != nil {
+		return nil, ErrBlockChainInsertFailed
+	}
+	return change, nil
+}
+
+// makes b the head in memory after updateHead's writes went through, and
+// tells the subscribers
+func (chain *BlockChain) moveHead(b *types.Block, change *headChange) error {
+	if change == nil {
+		chain.feeds.side.Send(ChainSideEvent{Block: b})
+		return nil
 	}
+	chain.head.Store(b)
+	chain.capSnapshot(snapshotLayers)
+	if len(change.dropped) > 0 {
+		slog.Info("chain reorganised", "head", b.Hash.Hex(), "dropped", len(change.dropped), "added", len(change.added))
+		chain.feeds.reorg.Send(ReorgEvent{OldChain: change.dropped, NewChain: change.added})
+	}
+	chain.feeds.head.Send(ChainHeadEvent{Block: b})
 	return nil
 }
 
-// checks if the chain is sane (AKA valid)
-func (chain *BlockChain) SanityCheck() bool {
-	lastBlock, err := chain.Last()
-	if err != nil || lastBlock == nil {
-		chain.Sane = false
-		return false
+// makes b the canonical block at its height and indexes its transactions,
+// writing to db
+func (chain *BlockChain) writeCanonical(db nexadb.KeyValueWriter, b *types.Block) error {
+	if err := db.Put(canonicalKey(b.Height), b.Hash.Bytes()); err != nil {
+		return ErrBlockChainInsertFailed
 	}
+	if chain.Options.IndexAddresses {
+		if err := chain.writeAddressIndex(db, b); err != nil {
+			return err
+		}
+	}
+	return chain.writeTxLookups(db, b)
+}
 
-	current := lastBlock
-	for current.ParentHash != (common.Hash{}) {
-		parent, ok := chain.BlocksMemory[current.ParentHash]
-		if !ok {
-			chain.Sane = false
-			return false
+// removes the indexes of a block that is no longer canonical, writing the
+// deletions to db
+func (chain *BlockChain) unwindCanonical(db nexadb.KeyValueWriter, b *types.Block) error {
+	if chain.Options.IndexAddresses {
+		if err := chain.deleteAddressIndex(db, b); err != nil {
+			return err
 		}
+	}
+	return chain.deleteTxLookups(db, b)
+}
 
-		if current.ParentHash.Hex() != parent.Hash.Hex() {
-			chain.Sane = false
-			return false
+// moves the canonical chain from oldHead's branch over to newHead's, writing
+// to db. the new branch may be shorter than the old one, so the canonical
+// entries of the old blocks above newHead are deleted as well
+func (chain *BlockChain) reorg(db nexadb.KeyValueWriter, oldHead, newHead *types.Block) (*headChange, error) {
+	var newChain []*types.Block
+	current := newHead
+	for current != nil {
+		canonical, err := chain.Database.Get(canonicalKey(current.Height))
+		if err == nil && common.Hash(canonical) == current.Hash {
+			break
+		}
+		newChain = append(newChain, current)
+		if current.ParentHash == GenesisParentHash {
+			break
 		}
+		current = chain.GetBlock(current.ParentHash)
+	}
+	if len(newChain) == 0 {
+		return &headChange{}, nil
+	}
+	forkHeight := newChain[len(newChain)-1].Height
 
-		current = parent
+	var oldChain []*types.Block
+	for current := oldHead; current != nil && current.Height >= forkHeight; current = chain.GetBlock(current.ParentHash) {
+		oldChain = append(oldChain, current)
 	}
 
-	chain.Sane = true
-	return true
+	for _, b := range oldChain {
+		if err := chain.unwindCanonical(db, b); err != nil {
+			return nil, err
+		}
+		if b.Height > newHead.Height {
+			if err := db.Delete(canonicalKey(b.Height)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		if err := chain.writeCanonical(db, newChain[i]); err != nil {
+			return nil, err
+		}
+	}
+	return &headChange{dropped: oldChain, added: newChain}, nil
+}
+
+// returns the validator set and proposer schedule of the epoch b belongs to
+// on b's branch. only proof of stake has validators
+func (chain *BlockChain) epochValidators(b *types.Block) (*epochTransition, error) {
+	pos, ok := chain.Engine.(*ProofOfStake)
+	if !ok || b == nil {
+		return nil, ErrNoValidators
+	}
+	return pos.transition(chain, b)
 }
 
-// validates the blocks from an electoral system.
-// a validator is picked from a pool of validators for now
-// a validator must stake a minimum of [x]nex to participate
-// if someone tries tricking the network, all of the staked crypto will be lost
-// TODO 'x' nex must be a reasonable amount as we start, but not reasonable enough that
-// everyone can participate.
-func (chain *BlockChain) pickValidator() (*Validator, error) {
-	prevBlock, err := chain.Previous()
+// returns the proposer schedule of the given epoch on the canonical chain,
+// which has to have reached the epoch
+func (chain *BlockChain) ProposerSchedule(epoch uint64) ([]common.Address, error) {
+	b := chain.GetHeaderByHeight(chain.Validators.epochStart(epoch))
+	if b == nil {
+		return nil, ErrEpochNotScheduled
+	}
+	t, err := chain.epochValidators(b)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching second to last block")
+		return nil, err
 	}
+	return slices.Clone(t.schedule), nil
+}
 
-	chain.LastBlock = prevBlock
+// returns the validators of the head's epoch in address order
+func (chain *BlockChain) ActiveValidators() ([]*ActiveValidator, error) {
+	t, err := chain.epochValidators(chain.head.Load())
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(t.validators), nil
+}
 
-	if chain.LastBlock == nil {
-		return nil, fmt.Errorf("LastBlock is nil")
+// this is equivilent to a BlockByHash function
+// retrieves a block by a given hash string
+func (chain *BlockChain) LocateBlock(hash string) *types.Block {
+	raw, err := hex.DecodeString(hash)
+	if err != nil || len(raw) != common.HashLength {
+		return nil
 	}
+	return chain.GetBlock(common.Hash(raw))
+}
 
-	address, err := chain.Validators.SelectValidator(chain.LastBlock.Hash.Bytes())
-	if err != nil {
-		return nil, ErrBlockChainValidatorSelectFailed
+// checks if the chain is sane (AKA valid)
+func (chain *BlockChain) SanityCheck() bool {
+	lastBlock, err := chain.Last()
+	if err != nil || lastBlock == nil {
+		chain.sane.Store(false)
+		return false
 	}
 
-	validator, ok := chain.Validators.Validators[address.Hex()]
-	if !ok || validator == nil {
-		return nil, fmt.Errorf("validator with address %s not found", address.Hex())
+	current := lastBlock
+	for current.ParentHash != GenesisParentHash {
+		parent := chain.GetBlock(current.ParentHash)
+		if parent == nil {
+			chain.sane.Store(false)
+			return false
+		}
+
+		if current.ParentHash.Hex() != parent.Hash.Hex() || current.Height != parent.Height+1 {
+			chain.sane.Store(false)
+			return false
+		}
+
+		current = parent
 	}
 
-	chain.Validators.SelectedValidator = validator
-	return validator, nil
+	chain.sane.Store(true)
+	return true
 }
 
-// picks a validator and uses them to validate the latest block in the chain
+// checks the latest block against the consensus rules and has the
+// authorized validator validate it
 func (chain *BlockChain) ValidateLastBlock() bool {
-	validator, err := chain.pickValidator()
-	if err != nil {
-		slog.Error("Failed to pick validator", "err", err)
+	pos, ok := chain.Engine.(*ProofOfStake)
+	if !ok {
+		slog.Error("chain is not running proof of stake")
 		return false
 	}
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
 
 	lastBlock, err := chain.Last()
 	if err != nil || lastBlock == nil {
 		slog.Error("Failed to load last block", "err", err)
 		return false
 	}
-
-	addr, err := validator.GetValidatorAddress()
-	if addr == "" {
-		slog.Error("Failed to get validator address", "err", err)
+	if err := pos.VerifyHeader(chain, lastBlock); err != nil {
+		slog.Error("last block breaks the consensus rules", "err", err)
+		return false
+	}
+	if pos.signer == nil {
+		slog.Error("no validator is authorized to validate the block")
+		return false
 	}
-	slog.Info("validator has been chosen", "addr", addr)
+	slog.Info("block was proposed by its scheduled validator", "proposer", lastBlock.Proposer.Hex())
 
-	return validator.ValidateBlock(lastBlock)
+	return pos.signer.ValidateBlock(lastBlock)
 }
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// reads the chain through every public read path until stop is closed
+func hammerReads(t *testing.T, chain *BlockChain, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		head := chain.CurrentBlock()
+		if head == nil {
+			continue
+		}
+		if chain.CurrentHeight() < head.Height {
+			t.Error("height went backwards while reading")
+			return
+		}
+		if b := chain.GetBlock(head.Hash); b == nil || b.Hash != head.Hash {
+			t.Errorf("head %x cannot be read back", head.Hash)
+			return
+		}
+		chain.GetHeader(head.ParentHash)
+		chain.GetBlockByHeight(head.Height / 2)
+		chain.GetHeaderByHeight(head.Height)
+		chain.Previous()
+		chain.FinalizedBlock()
+		chain.SanityCheck()
+		chain.Sane()
+		chain.CacheStats()
+		chain.ActiveValidators()
+		chain.ProposerSchedule(chain.Validators.EpochOf(head.Height))
+		for _, tx := range head.Transactions {
+			chain.GetTransaction(tx.Hash)
+		}
+		if statedb, err := chain.State(); err == nil {
+			statedb.GetBalance(common.Address{1})
+		}
+	}
+}
+
+// several writers insert the same blocks while readers hammer the chain, every
+// block must land once and the result must verify
+func TestConcurrentInsertAndRead(t *testing.T) {
+	w := setupTestWallet(t)
+	src := newTestChain(t, "src")
+	var blocks []*types.Block
+	for i := uint64(0); i < 24; i++ {
+		if i%4 == 3 {
+			blocks = append(blocks, insertTestBlock(t, src, signedTestTx(t, w, i/4, common.Address{1}, 1)))
+		} else {
+			blocks = append(blocks, insertTestBlock(t, src))
+		}
+	}
+	want := src.CurrentBlock()
+
+	dst := newTestChain(t, "dst")
+	heads := dst.SubscribeChainHeadEvent(len(blocks))
+	defer heads.Unsubscribe()
+
+	var (
+		stop    = make(chan struct{})
+		readers sync.WaitGroup
+		writers sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			hammerReads(t, dst, stop)
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for _, b := range blocks {
+				if existing := dst.GetBlockByHeight(b.Height); existing != nil && existing.Hash == b.Hash {
+					continue
+				}
+				// a writer that lost the race inserts a block that is already
+				// known, which must not disturb the chain
+				dst.Insert(b)
+			}
+		}()
+	}
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	if got := dst.CurrentBlock(); got == nil || got.Hash != want.Hash {
+		t.Fatalf("head %v, want %x", got, want.Hash)
+	}
+	for _, b := range blocks {
+		if got := dst.GetBlockByHeight(b.Height); got == nil || got.Hash != b.Hash {
+			t.Fatalf("canonical block %d is %v, want %x", b.Height, got, b.Hash)
+		}
+	}
+	if _, err := dst.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.SanityCheck() {
+		t.Fatal("chain is not sane")
+	}
+	statedb, err := dst.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statedb.GetNonce(w.Address) != 6 {
+		t.Fatalf("sender nonce %d, want 6", statedb.GetNonce(w.Address))
+	}
+
+	// every block extended the head once, so the subscriber saw each in order
+	if heads.Dropped() != 0 {
+		t.Fatalf("%d head events dropped", heads.Dropped())
+	}
+	for _, b := range blocks {
+		if ev := <-heads.Chan(); ev.Block.Hash != b.Hash {
+			t.Fatalf("head event for block %d, want %d", ev.Block.Height, b.Height)
+		}
+	}
+}
+
+// producers race to build on the head, so blocks of competing branches are
+// inserted and the head reorgs while readers hammer the chain
+func TestConcurrentForks(t *testing.T) {
+	setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+
+	var (
+		stop     = make(chan struct{})
+		readers  sync.WaitGroup
+		writers  sync.WaitGroup
+		inserted atomic.Int64
+		base     = time.Now().Unix() - 100000
+	)
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			hammerReads(t, chain, stop)
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		writers.Add(1)
+		go func(producer int64) {
+			defer writers.Done()
+			for j := int64(0); j < 16; j++ {
+				// every producer stamps its blocks differently, so blocks
+				// built on the same parent make different branches
+				b, err := chain.BuildBlock(base+j*10+producer, nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := chain.Engine.Seal(chain, b); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := chain.Insert(b); err == nil {
+					inserted.Add(1)
+				}
+			}
+		}(int64(i))
+	}
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	if inserted.Load() == 0 {
+		t.Fatal("no block was inserted")
+	}
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+	if !chain.SanityCheck() {
+		t.Fatal("chain is not sane")
+	}
+	head := chain.CurrentBlock()
+	for b := head; b.ParentHash != GenesisParentHash; b = chain.GetBlock(b.ParentHash) {
+		if got := chain.GetBlockByHeight(b.Height); got == nil || got.Hash != b.Hash {
+			t.Fatalf("canonical block %d is not on the branch of head %x", b.Height, head.Hash)
+		}
+	}
+}
+
+// opens a proof of work chain in dir whose difficulty halves or doubles each
+// block, so a short branch of fast blocks outweighs a long one of slow blocks.
+// alloc is funded at genesis and mines the blocks
+func newTestPowChain(t *testing.T, dir string, alloc common.Address) *BlockChain {
+	t.Helper()
+	config := *params.DefaultChainConfig
+	config.Consensus = params.ProofOfWork
+	config.Alloc = map[common.Address]uint64{alloc: 1_000_000_000}
+	config.Pow = &params.PowConfig{BlockTime: 10, GenesisDifficulty: 16, MinimumDifficulty: 1, BoundDivisor: 1}
+	chain, err := NewChainWithConfig(&config, &Options{DataDir: dir, IndexAddresses: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	chain.Engine.(*ProofOfWork).SetCoinbase(alloc)
+	return chain
+}
+
+// seals and inserts a block at time holding txs on the head of chain
+func insertTestBlockAt(t *testing.T, chain *BlockChain, time int64, txs ...*types.Transaction) *types.Block {
+	t.Helper()
+	b, err := chain.BuildBlock(time, txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Engine.Seal(chain, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Insert(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// a reorg onto a branch that is shorter but heavier must not leave the old
+// branch's blocks above the new head in the canonical chain or the indexes
+func TestReorgShorterHeavierBranch(t *testing.T) {
+	w := setupTestWallet(t)
+	base := time.Now().Unix() - 100000
+	chain := newTestPowChain(t, "db", w.Address)
+	other := newTestPowChain(t, "other", w.Address)
+
+	first := insertTestBlockAt(t, chain, base)
+	if err := other.Insert(first); err != nil {
+		t.Fatal(err)
+	}
+
+	// three slow blocks, the last two carrying transfers
+	var dropped []*types.Transaction
+	insertTestBlockAt(t, chain, base+100)
+	for i := uint64(0); i < 2; i++ {
+		tx := signedTestTx(t, w, i, common.Address{byte(i + 1)}, 1)
+		insertTestBlockAt(t, chain, base+int64(i+2)*100, tx)
+		dropped = append(dropped, tx)
+	}
+	if chain.CurrentHeight() != 4 {
+		t.Fatalf("head at %d, want 4", chain.CurrentHeight())
+	}
+
+	// one fast block on the first weighs more than the three slow ones
+	heavy := insertTestBlockAt(t, other, base+1)
+	if err := chain.Insert(heavy); err != nil {
+		t.Fatal(err)
+	}
+	if got := chain.CurrentBlock(); got.Hash != heavy.Hash {
+		t.Fatalf("head %d %x, want the heavier block %x", got.Height, got.Hash, heavy.Hash)
+	}
+	for height := uint64(3); height <= 4; height++ {
+		if b := chain.GetBlockByHeight(height); b != nil {
+			t.Fatalf("block %x is still canonical at %d above the head", b.Hash, height)
+		}
+	}
+	for _, tx := range dropped {
+		if _, _, err := chain.GetTransaction(tx.Hash); err == nil {
+			t.Fatalf("transaction %x of a dropped block is still indexed", tx.Hash)
+		}
+	}
+	for _, addr := range []common.Address{w.Address, {1}, {2}} {
+		history, err := chain.GetAddressHistory(addr, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 0 {
+			t.Fatalf("address %x keeps %d entries of dropped blocks", addr, len(history))
+		}
+	}
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the state the next block builds on is the heavy block's
+	next := insertTestBlockAt(t, chain, base+2)
+	if next.Height != 3 || next.ParentHash != heavy.Hash {
+		t.Fatalf("next block %d builds on %x", next.Height, next.ParentHash)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+// fork choice follows the heaviest branch. every block adds its difficulty
+// to the weight of the branch it extends; engines that do not set a
+// difficulty count each block as one, which makes the heaviest branch the
+// longest one. the running total is stored per block so comparing two heads
+// never walks the chain.
+
+// weight a block adds to its branch
+func blockWeight(b *types.Block) *big.Int {
+	if b.Difficulty == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).SetUint64(b.Difficulty)
+}
+
+// returns the total difficulty of the branch ending in the block with the
+// given hash. totals missing from the database, such as those of blocks
+// written before they were tracked, are worked out from the nearest known
+// ancestor and stored for next time
+func (chain *BlockChain) GetTd(hash common.Hash) *big.Int {
+	if data, err := chain.Database.Get(tdKey(hash)); err == nil {
+		return new(big.Int).SetBytes(data)
+	}
+
+	var missing []*types.Block
+	td := new(big.Int)
+	for current := chain.GetHeader(hash); current != nil; {
+		missing = append(missing, current)
+		if current.ParentHash == GenesisParentHash {
+			break
+		}
+		if data, err := chain.Database.Get(tdKey(current.ParentHash)); err == nil {
+			td.SetBytes(data)
+			break
+		}
+		// a chain started from a snapshot has no blocks below its base,
+		// the total starts counting there
+		current = chain.GetHeader(current.ParentHash)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		td.Add(td, blockWeight(missing[i]))
+		if !chain.Options.ReadOnly {
+			chain.Database.Put(tdKey(missing[i].Hash), td.Bytes())
+		}
+	}
+	return td
+}
+
+// writes the total difficulty of a new block, worked out from its parent's,
+// to db and returns it
+func (chain *BlockChain) writeTd(db nexadb.KeyValueWriter, b *types.Block) (*big.Int, error) {
+	td := new(big.Int)
+	if b.ParentHash != GenesisParentHash {
+		td = chain.GetTd(b.ParentHash)
+	}
+	td.Add(td, blockWeight(b))
+	if err := db.Put(tdKey(b.Hash), td.Bytes()); err != nil {
+		return nil, ErrBlockChainInsertFailed
+	}
+	return td, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// an engine that keeps state of its own next to the chain. a chain started
+// from a snapshot gets that state for its first block from the snapshot
+type checkpointEngine interface {
+	// returns the engine state after the block with the given hash
+	exportCheckpoint(chain consensus.ChainReader, hash common.Hash) ([]byte, error)
+
+	// takes data as the engine state after b
+	importCheckpoint(b *types.Block, data []byte) error
+}
+
+// creates the consensus engine named in the chain config. engines that keep
+// state of their own store it in db
+func CreateConsensusEngine(config *params.ChainConfig, pool *ValidatorPool, db nexadb.KeyValueStore) (consensus.Engine, error) {
+	switch config.Consensus {
+	case params.ProofOfStake, "":
+		return NewProofOfStake(pool), nil
+	case params.ProofOfWork:
+		return NewProofOfWork(config.Pow), nil
+	case params.ProofOfAuth:
+		return NewProofOfAuth(config.Poa, db), nil
+	}
+	return nil, fmt.Errorf("%w: %q", consensus.ErrUnknownEngine, config.Consensus)
+}
@@ -36,4 +36,68 @@ var (
 	ErrBlockChainInsertFailed = errors.New("failed to insert block into the chain")
 
 	ErrBlockChainValidatorSelectFailed = errors.New("failed to select validator for the chain")
+
+	ErrUnknownParent       = errors.New("block parent is not in the chain")
+	ErrBlockInvalidHeight  = errors.New("block height is not one above its parent")
+	ErrBlockBelowFinalized = errors.New("block conflicts with a finalized block")
+	ErrInvalidStateRoot    = errors.New("block state root does not match the state transition")
+	ErrNegativeAmount      = errors.New("transaction amount is negative")
+	ErrReceiptsNotFound    = errors.New("no receipts stored for block")
+	ErrInvalidTxHash       = errors.New("block tx hash does not match its transactions")
+	ErrTxNotFound          = errors.New("transaction is not in the canonical chain")
+	ErrTxHashMismatch      = errors.New("transaction hash does not match its contents")
+	ErrTxInvalidSig        = errors.New("transaction is not signed by its sender")
+	ErrTxInvalidNonce      = errors.New("transaction nonce is not the sender's account nonce")
+
+	ErrAddressIndexDisabled   = errors.New("address index is not enabled")
+	ErrAddressIndexIncomplete = errors.New("address index does not cover the whole chain, open the database with it enabled")
+
+	ErrPrunedDatabase = errors.New("database holds pruned state and cannot be opened as an archive node")
+	ErrGCMode         = errors.New("unknown gc mode")
+	ErrArchiveMode    = errors.New("state pruning is off in archive mode")
+
+	ErrSchemaTooNew = errors.New("database was written by a newer version and cannot be opened")
+
+	ErrReadOnlyDatabase = errors.New("chain database is opened read-only")
+	ErrBackupInvalid    = errors.New("backup is not a usable chain")
+
+	ErrChainCorrupt     = errors.New("chain database is inconsistent")
+	ErrSetHeadFinalized = errors.New("cannot rewind the head below the finalized block")
+
+	ErrFreezerMismatch = errors.New("freezer holds blocks the chain database does not know")
+
+	ErrExportRange   = errors.New("block range to export is not in the canonical chain")
+	ErrInvalidExport = errors.New("input is not a chain export")
+
+	ErrInvalidSnapshot   = errors.New("input is not a valid state snapshot")
+	ErrSnapshotRoot      = errors.New("state snapshot does not match its state root")
+	ErrSnapshotUntrusted = errors.New("state snapshot is not at a stored or trusted block")
+)
+
+var (
+	ErrVoteUnknownBlock       = errors.New("vote is for a block that is not on the canonical chain")
+	ErrVoteFinalized          = errors.New("vote is for a height that is already finalized")
+	ErrVoteUnknownSigner      = errors.New("vote is not from an active validator")
+	ErrVoteInvalidSig         = errors.New("vote signature is invalid")
+	ErrVoteEquivocation       = errors.New("validator already voted for a different block at this height")
+	ErrCertificateNotFound    = errors.New("no commit certificate for block")
+	ErrCertificateRewarded    = errors.New("commit certificate was already rewarded or is not older than the block")
+	ErrCertificateNotAncestor = errors.New("commit certificate is for a block outside this branch")
+	ErrCertificateNoQuorum    = errors.New("commit certificate does not hold precommits from 2/3 of the stake")
+)
+
+var (
+	ErrStakeZero    = errors.New("stake amount must be above zero")
+	ErrStakeExiting = errors.New("stake is already being paid back")
+	ErrNoStake      = errors.New("sender holds no stake")
+	ErrStakeCommit  = errors.New("first stake does not register a randao commitment")
+)
+
+var (
+	ErrRandaoExhausted     = errors.New("validator has no randao reveals left")
+	ErrRandaoUnknownSigner = errors.New("block proposer is not an active validator")
+	ErrInvalidProposer     = errors.New("block proposer is not the one scheduled for its height")
+	ErrRandaoInvalidReveal = errors.New("randao reveal does not match the proposer's commitment")
+	ErrRandaoInvalidMix    = errors.New("randao mix does not match the parent mix and reveal")
+	ErrBlockInvalidHash    = errors.New("block hash does not match its contents")
 )
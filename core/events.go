@@ -0,0 +1,79 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/event"
+)
+
+// posted when a block becomes the new head of the chain
+type ChainHeadEvent struct {
+	Block *types.Block
+}
+
+// posted when a valid block is stored but does not become the head
+type ChainSideEvent struct {
+	Block *types.Block
+}
+
+// posted when the head moves to another branch, before the ChainHeadEvent
+// for the new head. both chains are ordered from the highest block down
+type ReorgEvent struct {
+	OldChain []*types.Block
+	NewChain []*types.Block
+}
+
+// posted when transactions enter the transaction pool
+type NewTxsEvent struct {
+	Txs []*types.Transaction
+}
+
+// the feeds a chain posts to
+type chainFeeds struct {
+	head  event.Feed[ChainHeadEvent]
+	side  event.Feed[ChainSideEvent]
+	reorg event.Feed[ReorgEvent]
+}
+
+// subscribes to new heads, buffer <= 0 uses the default buffer
+func (chain *BlockChain) SubscribeChainHeadEvent(buffer int) *event.Subscription[ChainHeadEvent] {
+	return chain.feeds.head.Subscribe(buffer)
+}
+
+// subscribes to blocks stored off the canonical chain
+func (chain *BlockChain) SubscribeChainSideEvent(buffer int) *event.Subscription[ChainSideEvent] {
+	return chain.feeds.side.Subscribe(buffer)
+}
+
+// subscribes to reorganisations of the canonical chain, buffer <= 0 uses the
+// default buffer. reorg events are queued rather than dropped when the
+// subscriber falls behind, as the transactions of the old chain would
+// otherwise be lost
+func (chain *BlockChain) SubscribeReorgEvent(buffer int) *event.Subscription[ReorgEvent] {
+	return chain.feeds.reorg.SubscribeQueued(buffer)
+}
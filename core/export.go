@@ -0,0 +1,252 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// an export file starts with exportMagic and then holds one record per
+// block: the length of the encoded block as 4 big endian bytes followed by
+// the block in its canonical encoding. the whole stream may be gzipped
+var exportMagic = []byte("NEXCHAIN")
+
+const (
+	// largest encoded block Import accepts, guards against corrupt lengths
+	maxExportRecord = 64 << 20
+
+	// how often export and import report their progress
+	exportLogInterval = 8 * time.Second
+)
+
+// reports that an export wrote done of its total blocks
+type ExportProgress func(done, total uint64)
+
+// writes the canonical blocks from height from up to and including to into
+// w. the range is read from a database snapshot taken once, so a reorg
+// while exporting does not mix branches, and the chain is not locked while
+// the blocks are written. progress, if not nil, is called after each block
+func (chain *BlockChain) Export(w io.Writer, from, to uint64, progress ExportProgress) error {
+	chain.chainmu.Lock()
+	if err := chain.checkExportRange(from, to); err != nil {
+		chain.chainmu.Unlock()
+		return err
+	}
+	snap, err := chain.Database.NewSnapshot()
+	chain.chainmu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	if _, err := w.Write(exportMagic); err != nil {
+		return err
+	}
+	var (
+		start  = time.Now()
+		logged = start
+		total  = to - from + 1
+		parent common.Hash
+		length [4]byte
+	)
+	for height := from; height <= to; height++ {
+		hash, err := snap.Get(canonicalKey(height))
+		if err != nil {
+			return fmt.Errorf("%w: canonical block %d is missing", ErrExportRange, height)
+		}
+		// a rewind may delete the block after the snapshot was taken
+		b := chain.GetBlock(common.Hash(hash))
+		if b == nil {
+			return fmt.Errorf("%w: canonical block %d was removed while exporting", ErrExportRange, height)
+		}
+		if height > from && b.ParentHash != parent {
+			return fmt.Errorf("%w: block %d does not build on block %d", ErrExportRange, height, height-1)
+		}
+		parent = b.Hash
+		data := b.BytesStream()
+		binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+		if _, err := w.Write(length[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(height-from+1, total)
+		}
+		if time.Since(logged) > exportLogInterval {
+			slog.Info("exporting blocks", "height", height, "to", to, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+	}
+	slog.Info("exported blocks", "from", from, "to", to, "elapsed", time.Since(start))
+	return nil
+}
+
+func (chain *BlockChain) checkExportRange(from, to uint64) error {
+	if from < chain.tailHeight() || from > to || to > chain.CurrentHeight() {
+		return fmt.Errorf("%w: %d-%d, head is %d", ErrExportRange, from, to, chain.CurrentHeight())
+	}
+	return nil
+}
+
+// reads blocks written by Export from r and inserts them, so every block goes
+// through the same checks as one received from a peer. blocks that are
+// already canonical are skipped. gzipped input is detected and unpacked
+func (chain *BlockChain) Import(r io.Reader) error {
+	in, closer, err := openExportStream(r)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	magic := make([]byte, len(exportMagic))
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic) != string(exportMagic) {
+		return ErrInvalidExport
+	}
+
+	var (
+		start    = time.Now()
+		logged   = start
+		imported = 0
+		skipped  = 0
+		length   [4]byte
+	)
+	for {
+		if _, err := io.ReadFull(in, length[:]); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		size := binary.BigEndian.Uint32(length[:])
+		if size > maxExportRecord {
+			return fmt.Errorf("%w: block record of %d bytes", ErrInvalidExport, size)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(in, data); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		b := types.DecodeBlockBytesStream(data)
+
+		if existing := chain.GetBlockByHeight(b.Height); existing != nil && existing.Hash == b.Hash {
+			skipped++
+			continue
+		}
+		if err := chain.Insert(b); err != nil {
+			return fmt.Errorf("importing block %d: %w", b.Height, err)
+		}
+		imported++
+
+		if time.Since(logged) > exportLogInterval {
+			slog.Info("importing blocks", "height", b.Height, "imported", imported, "elapsed", time.Since(start))
+			logged = time.Now()
+		}
+	}
+	slog.Info("imported blocks", "imported", imported, "skipped", skipped, "head", chain.CurrentHeight(), "elapsed", time.Since(start))
+	return nil
+}
+
+// exports blocks into the file at path, gzipped if the path ends in .gz
+func (chain *BlockChain) ExportFile(path string, from, to uint64, progress ExportProgress) error {
+	if err := chain.checkExportRange(from, to); err != nil {
+		return err
+	}
+	return writeExportFile(path, func(w io.Writer) error {
+		return chain.Export(w, from, to, progress)
+	})
+}
+
+// creates the file at path and hands write a buffered writer into it, which
+// gzips when the path ends in .gz. a failure to close the file is returned,
+// as the export may not have reached the disk
+func writeExportFile(path string, write func(w io.Writer) error) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	var (
+		w  io.Writer = file
+		gz *gzip.Writer
+	)
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(file)
+		w = gz
+	}
+	buf := bufio.NewWriter(w)
+	if err := write(buf); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// wraps r in a buffered reader, unpacking it first if it is gzipped. the
+// returned func releases the gzip reader
+func openExportStream(r io.Reader) (*bufio.Reader, func(), error) {
+	in := bufio.NewReader(r)
+	magic, err := in.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return in, func() {}, nil
+	}
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bufio.NewReader(gz), func() { gz.Close() }, nil
+}
+
+// imports the blocks of an export file
+func (chain *BlockChain) ImportFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return chain.Import(file)
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// moves the test into a fresh directory holding a new wallet, which is the
+// one NewValidator loads
+func setupTestWallet(t *testing.T) *wallet.Wallet {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	w, err := wallet.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SaveDisk(); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// opens a proof of stake chain in dir with the wallet of the working
+// directory as its only validator
+func newTestChain(t *testing.T, dir string) *BlockChain {
+	t.Helper()
+	v, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *params.DefaultChainConfig
+	config.Validators = []params.GenesisValidator{v.GenesisValidator(params.Nex)}
+	chain, err := NewChainWithConfig(&config, &Options{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	chain.Engine.(*ProofOfStake).Authorize(v)
+	return chain
+}
+
+// builds, seals and inserts a block holding txs on the head of chain
+func insertTestBlock(t *testing.T, chain *BlockChain, txs ...*types.Transaction) *types.Block {
+	t.Helper()
+	b, err := chain.BuildBlock(time.Now().Unix()-1000+int64(chain.CurrentHeight()), txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Transactions) != len(txs) {
+		t.Fatalf("block %d holds %d of %d transactions", b.Height, len(b.Transactions), len(txs))
+	}
+	if err := chain.Engine.Seal(chain, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Insert(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// returns a transfer from w signed with the given nonce
+func signedTestTx(t *testing.T, w *wallet.Wallet, nonce uint64, to common.Address, amount int64) *types.Transaction {
+	t.Helper()
+	tx := types.NewTx(nonce, time.Now().Unix(), w.Address, to, amount, params.TxGasTransfer, 2000)
+	if err := SignTx(tx, w); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestExportImport(t *testing.T) {
+	w := setupTestWallet(t)
+	src := newTestChain(t, "src")
+	for i := 0; i < 4; i++ {
+		insertTestBlock(t, src)
+	}
+	insertTestBlock(t, src, signedTestTx(t, w, 0, common.Address{1}, 5), signedTestTx(t, w, 1, common.Address{2}, 7))
+	for i := 0; i < 4; i++ {
+		insertTestBlock(t, src)
+	}
+	head := src.CurrentBlock()
+
+	for _, name := range []string{"chain.export", "chain.export.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			var done, total uint64
+			progress := func(d, t uint64) { done, total = d, t }
+			if err := src.ExportFile(path, 1, head.Height, progress); err != nil {
+				t.Fatal(err)
+			}
+			if done != head.Height || total != head.Height {
+				t.Fatalf("progress ended at %d of %d, want %d of %d", done, total, head.Height, head.Height)
+			}
+
+			dst := newTestChain(t, filepath.Join(t.TempDir(), "db"))
+			if err := dst.ImportFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if got := dst.CurrentBlock(); got == nil || got.Hash != head.Hash {
+				t.Fatalf("imported head %v, want %x", got, head.Hash)
+			}
+			if _, err := dst.VerifyChain(); err != nil {
+				t.Fatal(err)
+			}
+			statedb, err := dst.State()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statedb.GetBalance(common.Address{2}) != 7 || statedb.GetNonce(w.Address) != 2 {
+				t.Fatalf("imported state has balance %d and nonce %d", statedb.GetBalance(common.Address{2}), statedb.GetNonce(w.Address))
+			}
+
+			// importing the same blocks again skips them
+			if err := dst.ImportFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if dst.CurrentBlock().Hash != head.Hash {
+				t.Fatal("reimport moved the head")
+			}
+		})
+	}
+}
+
+func TestExportRange(t *testing.T) {
+	setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	for i := 0; i < 3; i++ {
+		insertTestBlock(t, chain)
+	}
+	path := filepath.Join(t.TempDir(), "chain.export")
+	for _, r := range [][2]uint64{{0, 2}, {2, 1}, {1, 4}} {
+		if err := chain.ExportFile(path, r[0], r[1], nil); err == nil {
+			t.Errorf("exporting %d-%d succeeded", r[0], r[1])
+		}
+	}
+}
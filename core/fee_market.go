@@ -0,0 +1,144 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+var (
+	ErrInvalidBaseFee = errors.New("block base fee does not follow its parent")
+	ErrFeeCapTooLow   = errors.New("transaction max fee is below the block base fee")
+)
+
+const (
+	// number of recent blocks the fee estimate looks at by default
+	DefaultFeeHistoryBlocks = 20
+	// percentile of recent tips suggested to wallets
+	feeEstimatePercentile = 60
+)
+
+// returns the base fee a block built on parent must carry
+func CalcBaseFee(config *params.FeeMarketConfig, parent *types.Block) uint64 {
+	if config == nil {
+		return 0
+	}
+	if parent == nil {
+		return config.InitialBaseFee
+	}
+
+	base := parent.BaseFee
+	usage := parent.GasUsed
+	target := uint64(0)
+	if config.ElasticityMultiplier > 0 {
+		target = parent.GasLimit / config.ElasticityMultiplier
+	}
+	if target == 0 || usage == target {
+		return max(base, config.MinBaseFee)
+	}
+
+	// base * |usage - target| / target / denominator, in big ints so a large
+	// base fee cannot overflow
+	diff := usage - target
+	if usage < target {
+		diff = target - usage
+	}
+	d := new(big.Int).SetUint64(base)
+	d.Mul(d, new(big.Int).SetUint64(diff))
+	d.Div(d, new(big.Int).SetUint64(target))
+	d.Div(d, new(big.Int).SetUint64(config.ChangeDenominator))
+	delta := d.Uint64()
+
+	if usage > target {
+		return base + max(delta, 1)
+	}
+	if base < delta+config.MinBaseFee {
+		return config.MinBaseFee
+	}
+	return base - delta
+}
+
+// checks the block's base fee and that every transaction can pay it
+func (chain *BlockChain) verifyFeeMarket(b *types.Block) error {
+	config := chain.Config.FeeMarket
+	if config == nil {
+		return nil
+	}
+	if b.BaseFee != CalcBaseFee(config, chain.GetBlock(b.ParentHash)) {
+		return ErrInvalidBaseFee
+	}
+	for _, tx := range b.Transactions {
+		if _, ok := tx.EffectiveTip(b.BaseFee); !ok {
+			return ErrFeeCapTooLow
+		}
+	}
+	return nil
+}
+
+// what a wallet should put in a transaction to get into the next few blocks
+type FeeEstimate struct {
+	BaseFee uint64 // base fee of the next block
+	Tip     uint64 // tip recent transactions got in with
+	MaxFee  uint64 // leaves room for the base fee to double
+}
+
+// suggests fees from the tips paid over the last `blocks` canonical blocks
+func (chain *BlockChain) EstimateFee(blocks uint64) (*FeeEstimate, error) {
+	head, err := chain.Last()
+	if err != nil {
+		return nil, err
+	}
+	if blocks == 0 {
+		blocks = DefaultFeeHistoryBlocks
+	}
+
+	var tips []uint64
+	for current := head; current != nil && blocks > 0; blocks-- {
+		for _, tx := range current.Transactions {
+			if tip, ok := tx.EffectiveTip(current.BaseFee); ok {
+				tips = append(tips, tip)
+			}
+		}
+		if current.Height == 0 {
+			break
+		}
+		current = chain.GetBlockByHeight(current.Height - 1)
+	}
+
+	estimate := &FeeEstimate{BaseFee: CalcBaseFee(chain.Config.FeeMarket, head)}
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool { return tips[i] < tips[j] })
+		estimate.Tip = tips[(len(tips)-1)*feeEstimatePercentile/100]
+	}
+	estimate.MaxFee = 2*estimate.BaseFee + estimate.Tip
+	return estimate, nil
+}
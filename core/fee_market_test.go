@@ -0,0 +1,77 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+func TestCalcBaseFee(t *testing.T) {
+	config := &params.FeeMarketConfig{InitialBaseFee: 1000, MinBaseFee: 10, ElasticityMultiplier: 2, ChangeDenominator: 8}
+	tests := []struct {
+		name    string
+		baseFee uint64
+		used    uint64
+		want    uint64
+	}{
+		{"at the target", 1000, 5000, 1000},
+		{"full block", 1000, 10000, 1125},
+		{"empty block", 1000, 0, 875},
+		{"tiny rise still moves", 10, 5001, 11},
+		{"fall stops at the minimum", 11, 0, 10},
+		{"under the minimum", 5, 0, 10},
+	}
+	for _, tt := range tests {
+		parent := &types.Block{BaseFee: tt.baseFee, GasUsed: tt.used, GasLimit: 10000}
+		if got := CalcBaseFee(config, parent); got != tt.want {
+			t.Errorf("%s: base fee %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if got := CalcBaseFee(config, nil); got != config.InitialBaseFee {
+		t.Errorf("first block base fee %d, want %d", got, config.InitialBaseFee)
+	}
+	if got := CalcBaseFee(nil, &types.Block{BaseFee: 1000}); got != 0 {
+		t.Errorf("base fee %d without a fee market", got)
+	}
+	// a base fee near the top of the range does not wrap
+	huge := &types.Block{BaseFee: 1 << 62, GasUsed: 10000, GasLimit: 10000}
+	if got := CalcBaseFee(config, huge); got <= huge.BaseFee {
+		t.Errorf("base fee fell to %d after a full block", got)
+	}
+}
+
+// blocks have to carry the base fee their parent leads to, and the fee
+// estimate follows the tips blocks were built with
+func TestFeeMarketBlocks(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+
+	b, err := chain.BuildBlock(chain.CurrentBlock().Time+1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.BaseFee++
+	if err := chain.Engine.Seal(chain, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Insert(b); !errors.Is(err, ErrInvalidBaseFee) {
+		t.Fatalf("block with the wrong base fee: %v, want %v", err, ErrInvalidBaseFee)
+	}
+
+	tx := types.NewDynamicFeeTx(0, chain.CurrentBlock().Time, w.Address, common.Address{1}, 1, params.TxGasTransfer, 5000, 7)
+	if err := SignTx(tx, w); err != nil {
+		t.Fatal(err)
+	}
+	head := insertTestBlock(t, chain, tx)
+	estimate, err := chain.EstimateFee(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Tip != 7 || estimate.BaseFee != CalcBaseFee(chain.Config.FeeMarket, head) {
+		t.Fatalf("estimate %+v after a block tipping 7", estimate)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"math/bits"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// splits the tips collected in a block between the proposer, the treasury and
+// a burn. rounding leftovers go to the proposer
+func distributeFees(config *params.FeeConfig, statedb *state.StateDB, proposer common.Address, collected uint64) types.FeeTotals {
+	totals := types.FeeTotals{Collected: collected, Tips: collected}
+	if collected == 0 {
+		return totals
+	}
+	if config == nil {
+		statedb.AddBalance(proposer, collected)
+		totals.ToProposer = collected
+		return totals
+	}
+
+	// the shares add up to 100, checked when the config is loaded, so the
+	// three parts never take more than was collected
+	totals.Burned = percentOf(collected, config.BurnShare)
+	totals.ToProposer = percentOf(collected, config.ProposerShare)
+	if config.Treasury != (common.Address{}) {
+		totals.ToTreasury = percentOf(collected, config.TreasuryShare)
+	}
+	totals.ToProposer += collected - totals.Burned - totals.ToTreasury - totals.ToProposer
+
+	// fees were already taken out of the senders, so the burned part only
+	// has to leave the supply
+	statedb.Burn(totals.Burned)
+	if totals.ToTreasury > 0 {
+		statedb.AddBalance(config.Treasury, totals.ToTreasury)
+	}
+	statedb.AddBalance(proposer, totals.ToProposer)
+	return totals
+}
+
+// returns share percent of amount rounded down. the product is taken at 128
+// bits, so it cannot wrap however large amount is. share must not be above 100
+func percentOf(amount, share uint64) uint64 {
+	hi, lo := bits.Mul64(amount, share)
+	quo, _ := bits.Div64(hi, lo, 100)
+	return quo
+}
+
+// returns the fee totals of the block with the given hash
+func (chain *BlockChain) GetBlockFees(hash common.Hash) (*types.FeeTotals, error) {
+	receipts, err := chain.GetBlockReceipts(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &receipts.Fees, nil
+}
+
+// returns everything the state transition recorded about a block
+func (chain *BlockChain) GetBlockReceipts(hash common.Hash) (*types.BlockReceipts, error) {
+	if receipts, ok := chain.receiptsCache.Get(hash); ok {
+		return receipts.Copy(), nil
+	}
+	data, err := chain.Database.Get(receiptsKey(hash))
+	if err != nil {
+		if data, err = chain.readAncientReceipts(hash); err != nil {
+			return nil, ErrReceiptsNotFound
+		}
+	}
+	receipts := types.DecodeReceiptsBytesStream(data)
+	chain.receiptsCache.Add(hash, receipts.Copy())
+	return receipts, nil
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		amount, share, want uint64
+	}{
+		{0, 50, 0},
+		{99, 50, 49},
+		{1000, 30, 300},
+		{math.MaxUint64, 100, math.MaxUint64},
+		{math.MaxUint64, 70, 12912720851596686130},
+		{math.MaxUint64, 1, 184467440737095516},
+	}
+	for _, tt := range tests {
+		if got := percentOf(tt.amount, tt.share); got != tt.want {
+			t.Errorf("percentOf(%d, %d) = %d, want %d", tt.amount, tt.share, got, tt.want)
+		}
+	}
+}
+
+func TestDistributeFeesLarge(t *testing.T) {
+	statedb, err := state.New(state.EmptyRoot, memorydb.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &params.FeeConfig{ProposerShare: 60, BurnShare: 30, TreasuryShare: 10, Treasury: common.Address{9}}
+	proposer := common.Address{1}
+
+	collected := uint64(math.MaxUint64 / 2)
+	totals := distributeFees(config, statedb, proposer, collected)
+	if totals.Burned+totals.ToTreasury+totals.ToProposer != collected {
+		t.Fatalf("split %d + %d + %d does not add up to %d", totals.Burned, totals.ToTreasury, totals.ToProposer, collected)
+	}
+	if totals.Burned != percentOf(collected, 30) || totals.ToTreasury != collected/10 {
+		t.Fatalf("burned %d and treasury %d, want 30%% and 10%% of %d", totals.Burned, totals.ToTreasury, collected)
+	}
+	if statedb.GetBalance(proposer) != totals.ToProposer || statedb.GetBalance(config.Treasury) != totals.ToTreasury {
+		t.Fatal("balances do not match the split")
+	}
+}
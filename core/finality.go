@@ -0,0 +1,236 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"log/slog"
+	"math/big"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// finality works in two steps per height and round. validators prevote for
+// the block they see at the head, and once a block has prevotes from more
+// than 2/3 of the stake they precommit to it. a block with precommits of one
+// round from more than 2/3 of the stake is finalized: its commit certificate
+// is stored next to it and the fork choice will never leave its branch
+// again. a round that does not get there is followed by the next, in which
+// validators may vote for another block. the stake is that of the validator
+// set of the epoch the voted block belongs to.
+
+type voteKey struct {
+	height uint64
+	round  uint64
+	kind   types.VoteType
+}
+
+type finalityGadget struct {
+	votes map[voteKey]map[common.Address]*types.Vote
+}
+
+func newFinalityGadget() *finalityGadget {
+	return &finalityGadget{
+		votes: make(map[voteKey]map[common.Address]*types.Vote),
+	}
+}
+
+// signs a vote for a block in a round with the validator's wallet
+func (v *Validator) SignVote(kind types.VoteType, round uint64, b *types.Block) (*types.Vote, error) {
+	vote := &types.Vote{
+		Type:      kind,
+		Height:    b.Height,
+		Round:     round,
+		BlockHash: b.Hash,
+		Validator: v.Wallet.Address,
+		PublicKey: v.Wallet.PublicKeyBytes(),
+	}
+	sig, err := v.Wallet.Sign(vote.SigningHash())
+	if err != nil {
+		return nil, err
+	}
+	vote.Signature = sig
+	return vote, nil
+}
+
+// verifies a vote and counts it towards its block, finalizing the block
+// once it has both a prevote and a precommit quorum
+func (chain *BlockChain) AddVote(vote *types.Vote) error {
+	if chain.Options.ReadOnly {
+		return ErrReadOnlyDatabase
+	}
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	if finalized := chain.finalized.Load(); finalized != nil && vote.Height <= finalized.Height {
+		return ErrVoteFinalized
+	}
+	block := chain.GetBlockByHeight(vote.Height)
+	if block == nil || block.Hash != vote.BlockHash {
+		return ErrVoteUnknownBlock
+	}
+	validators, err := chain.epochValidators(block)
+	if err != nil || validators.validator(vote.Validator) == nil {
+		return ErrVoteUnknownSigner
+	}
+	if wallet.PubKeyToAddress(vote.PublicKey) != vote.Validator {
+		return ErrVoteInvalidSig
+	}
+	if !wallet.VerifySignature(vote.PublicKey, vote.SigningHash(), vote.Signature) {
+		return ErrVoteInvalidSig
+	}
+
+	key := voteKey{height: vote.Height, round: vote.Round, kind: vote.Type}
+	votes, ok := chain.finality.votes[key]
+	if !ok {
+		votes = make(map[common.Address]*types.Vote)
+		chain.finality.votes[key] = votes
+	}
+	if prev, ok := votes[vote.Validator]; ok {
+		if prev.BlockHash != vote.BlockHash {
+			slog.Warn("validator equivocated", "validator", vote.Validator.Hex(), "height", vote.Height, "round", vote.Round, "kind", vote.Type)
+			return ErrVoteEquivocation
+		}
+		return nil
+	}
+	votes[vote.Validator] = vote
+
+	// precommits can arrive before the prevotes that complete the prevote
+	// quorum, so both quorums are checked again after any vote
+	prevotes := voteKey{height: vote.Height, round: vote.Round, kind: types.Prevote}
+	precommits := voteKey{height: vote.Height, round: vote.Round, kind: types.Precommit}
+	if !chain.hasQuorum(validators, prevotes, vote.BlockHash) ||
+		!chain.hasQuorum(validators, precommits, vote.BlockHash) {
+		return nil
+	}
+	return chain.finalize(block, vote.Round)
+}
+
+// reports whether more than 2/3 of the stake of validators cast the votes
+// under key for hash
+func (chain *BlockChain) hasQuorum(validators *epochTransition, key voteKey, hash common.Hash) bool {
+	total := validators.totalWeight()
+	voted := new(big.Int)
+	for addr, vote := range chain.finality.votes[key] {
+		v := validators.validator(addr)
+		if v == nil || vote.BlockHash != hash {
+			continue
+		}
+		voted.Add(voted, new(big.Int).SetUint64(v.Stake))
+	}
+	// voted/total > 2/3
+	return voted.Mul(voted, big.NewInt(3)).Cmp(total.Mul(total, big.NewInt(2))) > 0
+}
+
+// checks that every precommit in the certificate of b is valid and that
+// together they hold more than 2/3 of the stake of b's epoch
+func (chain *BlockChain) verifyCertificate(cert *types.CommitCertificate, b *types.Block) error {
+	validators, err := chain.epochValidators(b)
+	if err != nil {
+		return err
+	}
+	total := validators.totalWeight()
+	voted := new(big.Int)
+	seen := make(map[common.Address]bool)
+	for _, vote := range cert.Precommits {
+		if vote.Type != types.Precommit || vote.Height != cert.Height || vote.Round != cert.Round || vote.BlockHash != cert.BlockHash {
+			return ErrVoteUnknownBlock
+		}
+		v := validators.validator(vote.Validator)
+		if v == nil {
+			return ErrVoteUnknownSigner
+		}
+		if wallet.PubKeyToAddress(vote.PublicKey) != vote.Validator ||
+			!wallet.VerifySignature(vote.PublicKey, vote.SigningHash(), vote.Signature) {
+			return ErrVoteInvalidSig
+		}
+		if seen[vote.Validator] {
+			return ErrVoteEquivocation
+		}
+		seen[vote.Validator] = true
+		voted.Add(voted, new(big.Int).SetUint64(v.Stake))
+	}
+	if voted.Mul(voted, big.NewInt(3)).Cmp(total.Mul(total, big.NewInt(2))) <= 0 {
+		return ErrCertificateNoQuorum
+	}
+	return nil
+}
+
+// stores the commit certificate for b made of the precommits of round and
+// marks b as the finalized block. the caller must hold chainmu
+func (chain *BlockChain) finalize(b *types.Block, round uint64) error {
+	cert := &types.CommitCertificate{
+		Height:    b.Height,
+		Round:     round,
+		BlockHash: b.Hash,
+	}
+	for _, vote := range chain.finality.votes[voteKey{height: b.Height, round: round, kind: types.Precommit}] {
+		if vote.BlockHash == b.Hash {
+			cert.Precommits = append(cert.Precommits, vote)
+		}
+	}
+	if err := chain.Database.Put(certificateKey(b.Hash), cert.BytesStream()); err != nil {
+		return err
+	}
+	if err := chain.Database.Put(finalizedBlockKey, b.Hash.Bytes()); err != nil {
+		return err
+	}
+	chain.finalized.Store(b)
+
+	for key := range chain.finality.votes {
+		if key.height <= b.Height {
+			delete(chain.finality.votes, key)
+		}
+	}
+	slog.Info("block finalized", "height", b.Height, "hash", b.Hash.Hex(), "precommits", len(cert.Precommits))
+
+	// the block is final either way, a failed freeze is retried next time
+	if err := chain.freeze(b.Height); err != nil {
+		slog.Error("failed to move finalized blocks to the freezer", "err", err)
+	}
+	return nil
+}
+
+// returns the certificate that finalized the block with the given hash
+func (chain *BlockChain) GetCommitCertificate(hash common.Hash) (*types.CommitCertificate, error) {
+	data, err := chain.Database.Get(certificateKey(hash))
+	if err != nil {
+		return nil, ErrCertificateNotFound
+	}
+	return types.DecodeCertificateBytesStream(data), nil
+}
+
+// reports whether b is the finalized block or one of its ancestors
+func (chain *BlockChain) IsFinalized(b *types.Block) bool {
+	finalized := chain.finalized.Load()
+	if finalized == nil || b.Height > finalized.Height {
+		return false
+	}
+	canonical := chain.GetBlockByHeight(b.Height)
+	return canonical != nil && canonical.Hash == b.Hash
+}
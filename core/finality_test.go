@@ -0,0 +1,151 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// signs a vote of kind for b in round and adds it to chain
+func addTestVote(t *testing.T, chain *BlockChain, v *Validator, kind types.VoteType, round uint64, b *types.Block) error {
+	t.Helper()
+	vote, err := v.SignVote(kind, round, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain.AddVote(vote)
+}
+
+// a validator votes once per height and round: a vote for another block is
+// equivocation in the same round and allowed in the next, which can then
+// finalize the block
+func TestFinalityRounds(t *testing.T) {
+	setupTestWallet(t)
+	chain, validators := newTestStakeChain(t, "db", 3, nil)
+	other, err := NewChainWithConfig(chain.Config, &Options{DataDir: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { other.Close() })
+	base := time.Now().Unix() - 1000
+
+	insertScheduledBlock(t, chain, validators, base+1)
+	first := insertScheduledBlock(t, chain, validators, base+2)
+	if err := addTestVote(t, chain, validators[0], types.Prevote, 0, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// a longer branch takes over and puts another block at the height
+	var branch []*types.Block
+	for i := int64(1); i <= 3; i++ {
+		branch = append(branch, insertScheduledBlock(t, other, validators, base+10+i))
+	}
+	for _, b := range branch {
+		if err := chain.Insert(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	second := branch[1]
+	if chain.GetBlockByHeight(second.Height).Hash != second.Hash {
+		t.Fatal("the longer branch did not become canonical")
+	}
+	if err := addTestVote(t, chain, validators[0], types.Prevote, 0, second); !errors.Is(err, ErrVoteEquivocation) {
+		t.Fatalf("second prevote in a round: %v, want %v", err, ErrVoteEquivocation)
+	}
+
+	// the first two validators hold 5 of the 6 stake
+	for _, v := range validators[:2] {
+		for _, kind := range []types.VoteType{types.Prevote, types.Precommit} {
+			if err := addTestVote(t, chain, v, kind, 1, second); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if finalized := chain.FinalizedBlock(); finalized == nil || finalized.Hash != second.Hash {
+		t.Fatal("block with a quorum in round 1 was not finalized")
+	}
+	cert, err := chain.GetCommitCertificate(second.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Round != 1 || len(cert.Precommits) != 2 {
+		t.Fatalf("certificate of round %d with %d precommits", cert.Round, len(cert.Precommits))
+	}
+	if err := chain.verifyCertificate(cert, second); err != nil {
+		t.Fatal(err)
+	}
+
+	// a precommit of another round does not count towards the certificate
+	stray := validators[2]
+	vote, err := stray.SignVote(types.Precommit, 0, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert.Precommits = append(cert.Precommits, vote)
+	if err := chain.verifyCertificate(cert, second); !errors.Is(err, ErrVoteUnknownBlock) {
+		t.Fatalf("certificate mixing rounds: %v, want %v", err, ErrVoteUnknownBlock)
+	}
+}
+
+// votes and certificates count the stake of the voted block's epoch, not
+// that of the head's
+func TestFinalityEpochValidators(t *testing.T) {
+	t.Chdir(t.TempDir())
+	joinerWallet, err := wallet.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	joiner := newValidator(joinerWallet)
+	chain, validators := newTestStakeChain(t, "db", 1, map[common.Address]uint64{joinerWallet.Address: 100 * params.Nex})
+	base := time.Now().Unix() - 1000
+
+	// the joiner stakes far more than the genesis validator, from epoch 1
+	stakeTx := types.NewStakeTx(0, base, joinerWallet.Address, int64(50*params.Nex), joiner.RandaoCommit, params.TxGasStake, 2000, 1)
+	if err := SignTx(stakeTx, joinerWallet); err != nil {
+		t.Fatal(err)
+	}
+	insertScheduledBlock(t, chain, validators, base+1, stakeTx)
+	voted := insertScheduledBlock(t, chain, validators, base+2)
+	validators = append(validators, joiner)
+	for height := int64(3); height <= 5; height++ {
+		insertScheduledBlock(t, chain, validators, base+height)
+	}
+	if !isActiveValidator(t, chain, joinerWallet.Address) {
+		t.Fatal("the joiner is not in the head's validator set")
+	}
+
+	if err := addTestVote(t, chain, joiner, types.Prevote, 0, voted); !errors.Is(err, ErrVoteUnknownSigner) {
+		t.Fatalf("vote from a validator of a later epoch: %v, want %v", err, ErrVoteUnknownSigner)
+	}
+	// the genesis validator holds all of the stake of epoch 0
+	for _, kind := range []types.VoteType{types.Prevote, types.Precommit} {
+		if err := addTestVote(t, chain, validators[0], kind, 0, voted); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if finalized := chain.FinalizedBlock(); finalized == nil || finalized.Hash != voted.Hash {
+		t.Fatal("block of epoch 0 was not finalized by the stake of epoch 0")
+	}
+	cert, err := chain.GetCommitCertificate(voted.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.verifyCertificate(cert, voted); err != nil {
+		t.Fatal(err)
+	}
+	// the same precommits are no quorum for a block of epoch 1
+	head := chain.CurrentBlock()
+	vote, err := validators[0].SignVote(types.Precommit, 0, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headCert := &types.CommitCertificate{Height: head.Height, BlockHash: head.Hash, Precommits: []*types.Vote{vote}}
+	if err := chain.verifyCertificate(headCert, head); !errors.Is(err, ErrCertificateNoQuorum) {
+		t.Fatalf("certificate of epoch 1 without the joiner: %v, want %v", err, ErrCertificateNoQuorum)
+	}
+}
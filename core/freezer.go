@@ -0,0 +1,277 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/freezer"
+)
+
+// tables of the chain freezer. item n of every table belongs to the
+// canonical block at height n+1
+const (
+	freezerHeaderTable  = "headers"
+	freezerBodyTable    = "bodies"
+	freezerReceiptTable = "receipts"
+)
+
+var freezerTables = []string{freezerHeaderTable, freezerBodyTable, freezerReceiptTable}
+
+// opens the freezer and finishes a freeze a crash interrupted
+func (chain *BlockChain) openFreezer() error {
+	dir := chain.Options.ancientDir()
+	if chain.Options.ReadOnly {
+		ancients, err := freezer.NewReadOnly(dir, freezerTables)
+		chain.Ancients = ancients
+		return err
+	}
+	ancients, err := freezer.New(dir, freezerTables)
+	if err != nil {
+		return err
+	}
+	chain.Ancients = ancients
+	if err := chain.repairFreezer(); err != nil {
+		ancients.Close()
+		return err
+	}
+	return nil
+}
+
+// returns the height of the last block in the freezer, zero if it is empty
+func (chain *BlockChain) FrozenHeight() uint64 {
+	return chain.Ancients.Items()
+}
+
+// moves every canonical block up to and including limit, which has to be
+// finalized, together with its receipts out of leveldb into the freezer.
+// the caller must hold chainmu
+func (chain *BlockChain) freeze(limit uint64) error {
+	first := chain.FrozenHeight() + 1
+	if limit < first {
+		return nil
+	}
+
+	var (
+		frozen []*types.Block
+		tail   = chain.tailHeight()
+	)
+	for height := first; height <= limit; height++ {
+		if height < tail {
+			// item n belongs to height n+1, so a chain started from a
+			// snapshot fills the heights below it with empty items
+			if err := chain.Ancients.Append(height-1, emptyFreezerItem()); err != nil {
+				return err
+			}
+			continue
+		}
+		b := chain.GetBlockByHeight(height)
+		if b == nil {
+			return ErrUnknownParent
+		}
+		receipts, err := chain.Database.Get(receiptsKey(b.Hash))
+		if err != nil {
+			receipts = (&types.BlockReceipts{}).BytesStream()
+		}
+		header := *b
+		header.Transactions = nil
+		err = chain.Ancients.Append(height-1, map[string][]byte{
+			freezerHeaderTable:  header.BytesStream(),
+			freezerBodyTable:    types.TxsBytesStream(b.Transactions),
+			freezerReceiptTable: receipts,
+		})
+		if err != nil {
+			return err
+		}
+		frozen = append(frozen, b)
+	}
+	if err := chain.Ancients.Sync(); err != nil {
+		return err
+	}
+
+	// only drop the leveldb copies once the freezer is on disk
+	for _, b := range frozen {
+		if err := chain.dropFrozen(b.Height, b.Hash); err != nil {
+			return err
+		}
+	}
+	if err := chain.dropSideBlocks(limit); err != nil {
+		return err
+	}
+	slog.Info("moved blocks to the freezer", "from", first, "to", limit)
+	return nil
+}
+
+// returns an item with an empty entry in every freezer table
+func emptyFreezerItem() map[string][]byte {
+	item := make(map[string][]byte, len(freezerTables))
+	for _, name := range freezerTables {
+		item[name] = []byte{}
+	}
+	return item
+}
+
+// deletes the blocks left in leveldb at or below limit. once the canonical
+// blocks there are frozen these can only be side blocks, which can never
+// become canonical again. leveldb only holds blocks above the freezer and
+// side blocks, so the scan stays small
+func (chain *BlockChain) dropSideBlocks(limit uint64) error {
+	iter := chain.Database.NewIterator(blockPrefix)
+	defer iter.Release()
+
+	batch := chain.Database.NewBatch()
+	dropped := 0
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(blockPrefix)+common.HashLength {
+			continue
+		}
+		hash := common.Hash(key[len(blockPrefix):])
+		b := types.DecodeBlockBytesStream(iter.Value())
+		if b.Height > limit {
+			continue
+		}
+		if canonical, err := chain.Database.Get(canonicalKey(b.Height)); err == nil && common.Hash(canonical) == hash {
+			continue
+		}
+		for _, key := range [][]byte{blockKey(hash), receiptsKey(hash), certificateKey(hash), tdKey(hash)} {
+			if err := batch.Delete(key); err != nil {
+				return err
+			}
+		}
+		dropped++
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if dropped == 0 {
+		return nil
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	slog.Info("dropped side blocks below the freezer", "count", dropped)
+	return nil
+}
+
+// points hash at its height in the freezer and removes the leveldb copies
+func (chain *BlockChain) dropFrozen(height uint64, hash common.Hash) error {
+	if err := chain.Database.Put(blockHeightKey(hash), encodeHeight(height)); err != nil {
+		return err
+	}
+	if err := chain.Database.Delete(blockKey(hash)); err != nil {
+		return err
+	}
+	return chain.Database.Delete(receiptsKey(hash))
+}
+
+// a crash can leave the freezer out of step with leveldb. a rewind that
+// stopped before truncating the freezer leaves blocks in it that are no
+// longer canonical, these are truncated away. a crash between syncing the
+// freezer and dropping the leveldb copies leaves frozen blocks without a
+// height entry, this redoes the drop for them
+func (chain *BlockChain) repairFreezer() error {
+	frozen, tail := chain.FrozenHeight(), chain.tailHeight()
+	height := frozen
+	for height >= tail && !chain.frozenCanonical(height) {
+		height--
+	}
+	if height < frozen {
+		if err := chain.Ancients.Truncate(height); err != nil {
+			return err
+		}
+		slog.Warn("truncated freezer to the canonical chain", "from", frozen, "to", height)
+	}
+
+	for ; height >= tail; height-- {
+		hash, err := chain.Database.Get(canonicalKey(height))
+		if err != nil {
+			return fmt.Errorf("%w: height %d", ErrFreezerMismatch, height)
+		}
+		if ok, _ := chain.Database.Has(blockHeightKey(common.Hash(hash))); ok {
+			return nil
+		}
+		if err := chain.dropFrozen(height, common.Hash(hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reports whether the freezer holds the canonical block at height
+func (chain *BlockChain) frozenCanonical(height uint64) bool {
+	hash, err := chain.Database.Get(canonicalKey(height))
+	if err != nil {
+		return false
+	}
+	header, err := chain.Ancients.Retrieve(freezerHeaderTable, height-1)
+	if err != nil {
+		return false
+	}
+	return types.DecodeBlockBytesStream(header).Hash == common.Hash(hash)
+}
+
+// returns the freezer height of a block that was moved there
+func (chain *BlockChain) frozenHeightOf(hash common.Hash) (uint64, bool) {
+	data, err := chain.Database.Get(blockHeightKey(hash))
+	if err != nil || len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// reads a block from the freezer, nil if it is not there
+func (chain *BlockChain) readAncientBlock(hash common.Hash) *types.Block {
+	height, ok := chain.frozenHeightOf(hash)
+	if !ok {
+		return nil
+	}
+	header, err := chain.Ancients.Retrieve(freezerHeaderTable, height-1)
+	if err != nil {
+		return nil
+	}
+	body, err := chain.Ancients.Retrieve(freezerBodyTable, height-1)
+	if err != nil {
+		return nil
+	}
+	block := types.DecodeBlockBytesStream(header)
+	block.Transactions = types.DecodeTxsBytesStream(body)
+	return block
+}
+
+// reads the encoded receipts of a block from the freezer
+func (chain *BlockChain) readAncientReceipts(hash common.Hash) ([]byte, error) {
+	height, ok := chain.frozenHeightOf(hash)
+	if !ok {
+		return nil, ErrReceiptsNotFound
+	}
+	return chain.Ancients.Retrieve(freezerReceiptTable, height-1)
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// finalizing a block moves it and the canonical blocks below it into the
+// freezer, where they are still read like any other block
+func TestFreezeFinalized(t *testing.T) {
+	setupTestWallet(t)
+	chain, validators := newTestStakeChain(t, "db", 1, nil)
+	base := time.Now().Unix() - 1000
+	var blocks []*types.Block
+	for i := int64(1); i <= 4; i++ {
+		blocks = append(blocks, insertScheduledBlock(t, chain, validators, base+i))
+	}
+
+	finalized := blocks[2]
+	for _, kind := range []types.VoteType{types.Prevote, types.Precommit} {
+		if err := addTestVote(t, chain, validators[0], kind, 0, finalized); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := chain.FrozenHeight(); got != finalized.Height {
+		t.Fatalf("frozen up to %d, want %d", got, finalized.Height)
+	}
+	check := func(chain *BlockChain) {
+		t.Helper()
+		for _, want := range blocks {
+			frozen := want.Height <= finalized.Height
+			if ok, _ := chain.Database.Has(blockKey(want.Hash)); ok == frozen {
+				t.Fatalf("block %d in leveldb: %v, frozen: %v", want.Height, ok, frozen)
+			}
+			got := chain.GetBlockByHeight(want.Height)
+			if got == nil || got.Hash != want.Hash || chain.GetBlock(want.Hash) == nil {
+				t.Fatalf("block %d cannot be read back", want.Height)
+			}
+		}
+	}
+	check(chain)
+
+	config := chain.Config
+	chain.Close()
+	chain, err := NewChainWithConfig(config, &Options{DataDir: "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	check(chain)
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+}
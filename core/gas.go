@@ -0,0 +1,115 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"errors"
+	"math/bits"
+
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+var (
+	ErrUnknownTxType   = errors.New("unknown transaction type")
+	ErrIntrinsicGas    = errors.New("transaction gas limit is below its intrinsic gas")
+	ErrGasLimitReached = errors.New("block gas limit reached")
+	ErrInvalidGasLimit = errors.New("block gas limit moved too far from its parent")
+	ErrInvalidGasUsed  = errors.New("block gas used does not match its transactions")
+	ErrFeeOverflow     = errors.New("transaction fee overflows")
+)
+
+// returns the gas a transaction costs before it does anything
+func IntrinsicGas(tx *types.Transaction) (uint64, error) {
+	switch tx.Type {
+	case types.TransferTx:
+		return params.TxGasTransfer, nil
+	case types.StakeTx, types.UnstakeTx:
+		return params.TxGasStake, nil
+	}
+	return 0, ErrUnknownTxType
+}
+
+// returns the gas limit for a block built on a parent with parentGasLimit,
+// moving it as far towards target as the bound allows
+func CalcGasLimit(parentGasLimit, target uint64) uint64 {
+	if target < params.MinGasLimit {
+		target = params.MinGasLimit
+	}
+	delta := parentGasLimit/params.GasLimitBoundDivisor - 1
+	limit := parentGasLimit
+	switch {
+	case limit < target:
+		limit = min(parentGasLimit+delta, target)
+	case limit > target:
+		limit = max(parentGasLimit-delta, target)
+	}
+	return limit
+}
+
+// returns the gas limit a producer should give a block built on parent
+func (chain *BlockChain) nextGasLimit(parent *types.Block) uint64 {
+	target := chain.Config.GasLimit
+	if target == 0 {
+		target = params.DefaultGasLimit
+	}
+	if parent == nil {
+		return target
+	}
+	return CalcGasLimit(parent.GasLimit, target)
+}
+
+// checks the block's gas limit stays within the bound of its parent's
+func (chain *BlockChain) verifyGasLimit(b *types.Block) error {
+	if b.GasLimit < params.MinGasLimit {
+		return ErrInvalidGasLimit
+	}
+	if b.GasUsed > b.GasLimit {
+		return ErrGasLimitReached
+	}
+	parent := chain.GetBlock(b.ParentHash)
+	if parent == nil {
+		return nil
+	}
+	diff := b.GasLimit - parent.GasLimit
+	if b.GasLimit < parent.GasLimit {
+		diff = parent.GasLimit - b.GasLimit
+	}
+	if diff >= parent.GasLimit/params.GasLimitBoundDivisor {
+		return ErrInvalidGasLimit
+	}
+	return nil
+}
+
+// returns gas * price, or an error if it does not fit in a uint64
+func gasCost(gas, price uint64) (uint64, error) {
+	hi, lo := bits.Mul64(gas, price)
+	if hi != 0 {
+		return 0, ErrFeeOverflow
+	}
+	return lo, nil
+}
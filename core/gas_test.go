@@ -0,0 +1,80 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+func TestIntrinsicGas(t *testing.T) {
+	tests := []struct {
+		tx   *types.Transaction
+		want uint64
+		err  error
+	}{
+		{types.NewTx(0, 0, common.Address{}, common.Address{1}, 1, 0, 0), params.TxGasTransfer, nil},
+		{types.NewStakeTx(0, 0, common.Address{}, 1, common.Hash{}, 0, 0, 0), params.TxGasStake, nil},
+		{types.NewUnstakeTx(0, 0, common.Address{}, 0, 0, 0), params.TxGasStake, nil},
+		{&types.Transaction{Type: 0xff}, 0, ErrUnknownTxType},
+	}
+	for _, tt := range tests {
+		got, err := IntrinsicGas(tt.tx)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("type %d: %d, %v, want %d, %v", tt.tx.Type, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCalcGasLimit(t *testing.T) {
+	parent := uint64(10_000_000)
+	step := parent/params.GasLimitBoundDivisor - 1
+	tests := []struct {
+		name   string
+		target uint64
+		want   uint64
+	}{
+		{"at the target", parent, parent},
+		{"rises by the bound", 2 * parent, parent + step},
+		{"falls by the bound", parent / 2, parent - step},
+		{"stops at a close target", parent + 10, parent + 10},
+		{"target under the minimum", 0, parent - step},
+	}
+	for _, tt := range tests {
+		if got := CalcGasLimit(parent, tt.target); got != tt.want {
+			t.Errorf("%s: gas limit %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if got := CalcGasLimit(params.MinGasLimit, 0); got != params.MinGasLimit {
+		t.Errorf("gas limit fell to %d, below the minimum", got)
+	}
+
+	if _, err := gasCost(math.MaxUint64, 2); !errors.Is(err, ErrFeeOverflow) {
+		t.Errorf("overflowing fee: %v, want %v", err, ErrFeeOverflow)
+	}
+	if cost, err := gasCost(params.TxGasTransfer, 1000); err != nil || cost != params.TxGasTransfer*1000 {
+		t.Errorf("fee %d, %v", cost, err)
+	}
+}
+
+// a block's gas limit may only move within the bound of its parent's
+func TestVerifyGasLimit(t *testing.T) {
+	setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+
+	b, err := chain.BuildBlock(chain.CurrentBlock().Time+1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.GasLimit += b.GasLimit / params.GasLimitBoundDivisor
+	if err := chain.Engine.Seal(chain, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Insert(b); !errors.Is(err, ErrInvalidGasLimit) {
+		t.Fatalf("block with a gas limit past the bound: %v, want %v", err, ErrInvalidGasLimit)
+	}
+}
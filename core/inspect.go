@@ -0,0 +1,317 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/snapshot"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// a group of keys in the chain database, named for inspection tools
+type namespace struct {
+	name   string
+	prefix []byte
+	decode func(value []byte) (any, error)
+}
+
+var namespaces = []namespace{
+	{"blocks", blockPrefix, func(v []byte) (any, error) { return types.DecodeBlockBytesStream(v), nil }},
+	{"canonical", canonicalPrefix, decodeHash},
+	{"certificates", certificatePrefix, func(v []byte) (any, error) { return types.DecodeCertificateBytesStream(v), nil }},
+	{"receipts", receiptsPrefix, func(v []byte) (any, error) { return types.DecodeReceiptsBytesStream(v), nil }},
+	{"txlookups", txLookupPrefix, func(v []byte) (any, error) { return types.DecodeTxLookupBytesStream(v), nil }},
+	{"addresses", addressPrefix, decodeAddressEntry},
+	{"heights", blockHeightPrefix, decodeHeight},
+	{"td", tdPrefix, func(v []byte) (any, error) { return new(big.Int).SetBytes(v), nil }},
+	{"poacheckpoints", poaCheckpointPrefix, func(v []byte) (any, error) { return decodePoaSnapshot(v) }},
+	{"stateroots", []byte(state.RootPrefix), state.DecodeRoot},
+	{"statenodes", []byte(state.NodePrefix), state.DecodeNode},
+	{"snapshot", []byte(snapshot.AccountPrefix), snapshot.DecodeAccount},
+}
+
+// single keys holding chain metadata
+var metadataKeys = map[string]func(value []byte) (any, error){
+	string(headBlockKey):      decodeHash,
+	string(finalizedBlockKey): decodeHash,
+	string(gcModeKey):         func(v []byte) (any, error) { return string(v), nil },
+	string(schemaVersionKey):  decodeHeight,
+	string(migrationKey):      func(v []byte) (any, error) { return fmt.Sprintf("%x", v), nil },
+	string(tailBlockKey):      decodeHash,
+	string(baselineKey):       decodeHeight,
+	string(addressIndexedKey): func(v []byte) (any, error) { return true, nil },
+	"SnapshotRoot":            snapshot.DecodeRoot,
+}
+
+func decodeHash(v []byte) (any, error) {
+	if len(v) != common.HashLength {
+		return nil, fmt.Errorf("value is %d bytes, not a hash", len(v))
+	}
+	return common.Hash(v).Hex(), nil
+}
+
+func decodeHeight(v []byte) (any, error) {
+	if len(v) != 8 {
+		return nil, fmt.Errorf("value is %d bytes, not a height", len(v))
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func decodeAddressEntry(v []byte) (any, error) {
+	if len(v) != common.HashLength+1 {
+		return nil, fmt.Errorf("value is %d bytes, not an address index entry", len(v))
+	}
+	role := v[common.HashLength]
+	return &AddressTx{
+		TxHash:   common.Hash(v[:common.HashLength]),
+		Sent:     role&addressSent != 0,
+		Received: role&addressReceived != 0,
+	}, nil
+}
+
+// returns the namespace a key belongs to, "metadata" for the single keys and
+// "unknown" for anything else
+func keyNamespace(key []byte) string {
+	if _, ok := metadataKeys[string(key)]; ok {
+		return "metadata"
+	}
+	for _, ns := range namespaces {
+		if bytes.HasPrefix(key, ns.prefix) {
+			return ns.name
+		}
+	}
+	return "unknown"
+}
+
+// key count and size of one namespace of the chain database
+type NamespaceStat struct {
+	Name string
+	Keys uint64
+	Size uint64 // bytes of keys and values together
+}
+
+// counts the keys and bytes of every namespace in the chain database. the
+// freezer is reported as "ancient" with one key per frozen block
+func (chain *BlockChain) InspectDatabase() ([]NamespaceStat, error) {
+	stats := make(map[string]*NamespaceStat)
+	order := []string{"metadata"}
+	for _, ns := range namespaces {
+		order = append(order, ns.name)
+	}
+	order = append(order, "unknown")
+	for _, name := range order {
+		stats[name] = &NamespaceStat{Name: name}
+	}
+
+	iter := chain.Database.NewIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		stat := stats[keyNamespace(iter.Key())]
+		stat.Keys++
+		stat.Size += uint64(len(iter.Key()) + len(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	result := make([]NamespaceStat, 0, len(order)+1)
+	for _, name := range order {
+		result = append(result, *stats[name])
+	}
+	return append(result, NamespaceStat{Name: "ancient", Keys: chain.FrozenHeight()}), nil
+}
+
+// returns the names of the namespaces DumpNamespace accepts
+func Namespaces() []string {
+	names := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		names[i] = ns.name
+	}
+	return names
+}
+
+// looks up key in the chain database and decodes its value according to
+// the namespace it is in. it returns the namespace and the decoded value
+func (chain *BlockChain) DecodeKey(key []byte) (string, any, error) {
+	value, err := chain.Database.Get(key)
+	if err != nil {
+		return "", nil, err
+	}
+	name, decoded, err := decodeEntry(key, value)
+	return name, decoded, err
+}
+
+func decodeEntry(key, value []byte) (string, any, error) {
+	if decode, ok := metadataKeys[string(key)]; ok {
+		decoded, err := decode(value)
+		return "metadata", decoded, err
+	}
+	for _, ns := range namespaces {
+		if bytes.HasPrefix(key, ns.prefix) {
+			decoded, err := ns.decode(value)
+			return ns.name, decoded, err
+		}
+	}
+	return "unknown", value, nil
+}
+
+// calls fn with up to limit keys of the named namespace and their decoded
+// values, in key order. a zero limit means every key
+func (chain *BlockChain) DumpNamespace(name string, limit uint64, fn func(key []byte, value any, err error)) error {
+	for _, ns := range namespaces {
+		if ns.name != name {
+			continue
+		}
+		iter := chain.Database.NewIterator(ns.prefix)
+		defer iter.Release()
+		for n := uint64(0); iter.Next() && (limit == 0 || n < limit); n++ {
+			value, err := ns.decode(iter.Value())
+			fn(append([]byte{}, iter.Key()...), value, err)
+		}
+		return iter.Error()
+	}
+	return fmt.Errorf("unknown namespace %q", name)
+}
+
+// walks the canonical chain from its first block to the head checking that
+// every block is stored, hashes to its key, links to the canonical block
+// below it, matches its tx hash and, where the parent state is still
+// stored, re-executes to its state root. blocks from the baseline layout
+// were hashed over gob and predate the state, so they are only checked
+// against their key and not re-executed. it returns the height up to which
+// the chain is consistent and what is wrong with the block after it
+func (chain *BlockChain) VerifyChain() (uint64, error) {
+	return chain.verifyChain()
+}
+
+// does the work of VerifyChain. Repair calls it holding chainmu so nothing
+// can be inserted between finding the last good block and rewinding to it
+func (chain *BlockChain) verifyChain() (uint64, error) {
+	var (
+		head     = chain.CurrentHeight()
+		tail     = chain.tailHeight()
+		baseline = chain.baselineHeight()
+		parent   = GenesisParentHash
+		logged   = time.Now()
+		executed = 0
+	)
+	if tail > 1 {
+		// a chain started from a snapshot links up to a block it does not hold
+		if b := chain.GetHeaderByHeight(tail); b != nil {
+			parent = b.ParentHash
+		}
+	}
+	for height := tail; height <= head; height++ {
+		hash, err := chain.Database.Get(canonicalKey(height))
+		if err != nil {
+			return height - 1, fmt.Errorf("%w: no canonical block at height %d", ErrChainCorrupt, height)
+		}
+		b := chain.readBlock(common.Hash(hash))
+		if b == nil {
+			return height - 1, fmt.Errorf("%w: block %d %x is missing", ErrChainCorrupt, height, hash)
+		}
+		switch {
+		case b.Hash != common.Hash(hash) || (height > baseline && b.ComputeHash() != b.Hash):
+			return height - 1, fmt.Errorf("%w: block %d does not hash to its key", ErrChainCorrupt, height)
+		case b.Height != height:
+			return height - 1, fmt.Errorf("%w: block at height %d says it is %d", ErrChainCorrupt, height, b.Height)
+		case b.ParentHash != parent:
+			return height - 1, fmt.Errorf("%w: block %d does not link to block %d", ErrChainCorrupt, height, height-1)
+		case b.TxHash != types.DeriveTxHash(b.Transactions):
+			return height - 1, fmt.Errorf("%w: block %d tx hash does not match its transactions", ErrChainCorrupt, height)
+		}
+		parent = b.Hash
+		if height == tail && tail > 1 {
+			// the first block of a chain started from a snapshot has no
+			// parent state to re-execute on, its state was checked on import
+			continue
+		}
+		if height <= baseline {
+			// blocks from the baseline layout predate the state
+			continue
+		}
+		ok, err := chain.verifyStateRoot(b)
+		if err != nil {
+			return height - 1, fmt.Errorf("%w: block %d: %v", ErrChainCorrupt, height, err)
+		}
+		if ok {
+			executed++
+		}
+
+		if time.Since(logged) > exportLogInterval {
+			slog.Info("verifying chain", "height", height, "head", head)
+			logged = time.Now()
+		}
+	}
+	slog.Info("verified chain", "head", head, "executed", executed)
+	return head, nil
+}
+
+// re-executes b on its parent state and compares the result with its state
+// root. it reports false without an error when the parent state was pruned
+func (chain *BlockChain) verifyStateRoot(b *types.Block) (bool, error) {
+	statedb, err := chain.ParentState(b)
+	if err == state.ErrMissingRoot {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := chain.applyBlock(statedb, b); err != nil {
+		return false, err
+	}
+	if statedb.IntermediateRoot() != b.StateRoot {
+		return false, ErrInvalidStateRoot
+	}
+	return true, nil
+}
+
+// verifies the chain and rewinds the head to the last consistent block. it
+// returns the height of the new head, which is the old one if nothing was wrong
+func (chain *BlockChain) Repair() (uint64, error) {
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	good, err := chain.verifyChain()
+	if err == nil {
+		return good, nil
+	}
+	slog.Warn("chain is inconsistent, rewinding", "err", err, "to", good)
+
+	if _, err := chain.setHead(good); err != nil {
+		return 0, err
+	}
+	return good, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// returns the number of new coins paid out for the block at height, given
+// the supply before the block
+func BlockReward(config *params.IssuanceConfig, height uint64, supply uint64) uint64 {
+	if config == nil {
+		return 0
+	}
+	switch config.Curve {
+	case params.IssuanceFixed:
+		return config.BlockReward
+	case params.IssuanceHalving:
+		if config.HalvingInterval == 0 {
+			return config.BlockReward
+		}
+		halvings := height / config.HalvingInterval
+		if halvings >= 64 {
+			return 0
+		}
+		return config.BlockReward >> halvings
+	case params.IssuanceInflation:
+		if config.BlocksPerYear == 0 {
+			return 0
+		}
+		// supply * bps / 10000 / blocksPerYear, in big ints so it cannot overflow
+		reward := new(big.Int).SetUint64(supply)
+		reward.Mul(reward, new(big.Int).SetUint64(config.InflationBasisPoints))
+		reward.Div(reward, big.NewInt(10_000))
+		reward.Div(reward, new(big.Int).SetUint64(config.BlocksPerYear))
+		return reward.Uint64()
+	}
+	return 0
+}
+
+// mints the block reward. if the block carries a commit certificate the
+// configured voter share is split evenly between its signers and the
+// proposer keeps the rest
+func (chain *BlockChain) applyRewards(statedb *state.StateDB, b *types.Block) error {
+	author, err := chain.Engine.Author(b)
+	if err != nil {
+		return err
+	}
+	config := chain.Config.Issuance
+	reward := BlockReward(config, b.Height, statedb.Supply())
+
+	if cert := b.Certificate; cert != nil {
+		if err := chain.verifyRewardCertificate(statedb, b, cert); err != nil {
+			return err
+		}
+		statedb.SetRewardedHeight(cert.Height)
+
+		if config != nil && config.VoterShare > 0 && len(cert.Precommits) > 0 {
+			pool := percentOf(reward, config.VoterShare)
+			share := pool / uint64(len(cert.Precommits))
+			for _, vote := range cert.Precommits {
+				statedb.Mint(vote.Validator, share)
+			}
+			reward -= share * uint64(len(cert.Precommits))
+		}
+	}
+
+	statedb.Mint(author, reward)
+	return nil
+}
+
+// a certificate can only be paid out once, for a block on this branch
+func (chain *BlockChain) verifyRewardCertificate(statedb *state.StateDB, b *types.Block, cert *types.CommitCertificate) error {
+	if cert.Height >= b.Height || cert.Height <= statedb.RewardedHeight() {
+		return ErrCertificateRewarded
+	}
+	current := b
+	for current.Height > cert.Height {
+		if current = chain.GetBlock(current.ParentHash); current == nil {
+			return ErrUnknownParent
+		}
+	}
+	if current.Hash != cert.BlockHash {
+		return ErrCertificateNotAncestor
+	}
+	return chain.verifyCertificate(cert, current)
+}
+
+// returns the total NEX in existence at the head of the chain
+func (chain *BlockChain) TotalSupply() (uint64, error) {
+	statedb, err := chain.State()
+	if err != nil {
+		return 0, err
+	}
+	return statedb.Supply(), nil
+}
+
+// returns the balance of addr at the head of the chain
+func (chain *BlockChain) GetBalance(addr common.Address) (uint64, error) {
+	account, err := chain.headAccount(addr)
+	if err != nil {
+		return 0, err
+	}
+	return account.Balance, nil
+}
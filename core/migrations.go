@@ -0,0 +1,305 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/leveldb"
+)
+
+// SchemaVersion is the layout of the chain database this code reads and
+// writes. bump it together with a new entry in migrations whenever a key or
+// an encoding changes
+const SchemaVersion = 1
+
+const (
+	// heights a migration handles between saving its cursor
+	migrationBatch = 1024
+
+	// how often a running migration reports its progress
+	migrationLogInterval = 8 * time.Second
+)
+
+// migration upgrades the database from version-1 to version. run starts at
+// cursor, which is wherever an interrupted run got to, and has to be safe to
+// repeat for the heights after the last saved cursor
+type migration struct {
+	version uint64
+	name    string
+	run     func(chain *BlockChain, cursor uint64) error
+}
+
+var migrations = []migration{
+	{version: 1, name: "index canonical transactions", run: migrateTxLookups},
+}
+
+// reads the schema version of db. a new database gets the current version,
+// one from before versioning, including the baseline layout, reads as
+// version 0
+func checkSchemaVersion(db *leveldb.Database, readOnly bool) (uint64, error) {
+	data, err := db.Get(schemaVersionKey)
+	if err == nil && len(data) == 8 {
+		version := binary.BigEndian.Uint64(data)
+		if version > SchemaVersion {
+			return 0, fmt.Errorf("%w: version %d, supported %d", ErrSchemaTooNew, version, SchemaVersion)
+		}
+		return version, nil
+	}
+	if ok, _ := db.Has(headBlockKey); ok || isBaselineLayout(db) {
+		return 0, nil
+	}
+	if readOnly {
+		return SchemaVersion, nil
+	}
+	return SchemaVersion, db.Put(schemaVersionKey, encodeHeight(SchemaVersion))
+}
+
+// brings the database from version up to SchemaVersion one migration at a
+// time, picking up an interrupted migration where it stopped
+func (chain *BlockChain) migrate(version uint64) error {
+	if chain.Options.ReadOnly && version < SchemaVersion {
+		return fmt.Errorf("%w: version %d has to be migrated first", ErrReadOnlyDatabase, version)
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		cursor := uint64(0)
+		if data, err := chain.Database.Get(migrationKey); err == nil && len(data) == 16 &&
+			binary.BigEndian.Uint64(data[:8]) == m.version {
+			cursor = binary.BigEndian.Uint64(data[8:])
+			slog.Info("resuming database migration", "version", m.version, "name", m.name, "cursor", cursor)
+		} else {
+			slog.Info("migrating database", "version", m.version, "name", m.name)
+		}
+		if err := m.run(chain, cursor); err != nil {
+			return fmt.Errorf("migrating database to version %d: %w", m.version, err)
+		}
+		if err := chain.Database.Put(schemaVersionKey, encodeHeight(m.version)); err != nil {
+			return err
+		}
+		if err := chain.Database.Delete(migrationKey); err != nil {
+			return err
+		}
+		slog.Info("database migrated", "version", m.version)
+	}
+	return nil
+}
+
+// saves how far migration version got
+func (chain *BlockChain) saveMigrationCursor(version, cursor uint64) error {
+	return chain.Database.Put(migrationKey, append(encodeHeight(version), encodeHeight(cursor)...))
+}
+
+// databases from before the tx lookup index have no entries for the
+// transactions already in the chain
+func migrateTxLookups(chain *BlockChain, cursor uint64) error {
+	var (
+		head   = chain.CurrentHeight()
+		logged = time.Now()
+	)
+	for height := max(cursor, 1); height <= head; height++ {
+		b := chain.GetBlockByHeight(height)
+		if b == nil {
+			return fmt.Errorf("canonical block %d is missing", height)
+		}
+		if err := chain.writeTxLookups(chain.Database, b); err != nil {
+			return err
+		}
+		if height%migrationBatch == 0 {
+			if err := chain.saveMigrationCursor(1, height+1); err != nil {
+				return err
+			}
+		}
+		if time.Since(logged) > migrationLogInterval {
+			slog.Info("indexing canonical transactions", "height", height, "head", head)
+			logged = time.Now()
+		}
+	}
+	return nil
+}
+
+// returns the height up to which blocks were converted from the baseline
+// layout, zero if none were
+func (chain *BlockChain) baselineHeight() uint64 {
+	return chain.readHeight(baselineKey)
+}
+
+// reads a height stored under a metadata key, zero if it is not there
+func (chain *BlockChain) readHeight(key []byte) uint64 {
+	data, err := chain.Database.Get(key)
+	if err != nil || len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// a block as the first release stored it
+type baselineBlock struct {
+	Time         int64
+	Hash         common.Hash
+	ParentHash   common.Hash
+	UncleHash    common.Hash
+	Transactions []*types.Transaction
+	TxHash       common.Hash
+	Height       int // never set
+}
+
+// reports whether db has the baseline layout, which kept nothing but blocks
+// under their raw 32 byte hashes. versions up to the randao mix wrote it too
+func isBaselineLayout(db *leveldb.Database) bool {
+	iter := db.NewIterator(nil)
+	defer iter.Release()
+	return iter.Next() && len(iter.Key()) == common.HashLength
+}
+
+// decodes a block stored in the baseline layout. the first release had a
+// signed height, which gob will not decode into the current block
+func decodeBaselineBlock(data []byte) (*types.Block, error) {
+	var b types.Block
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&b); err == nil {
+		return &b, nil
+	}
+	var old baselineBlock
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&old); err != nil {
+		return nil, err
+	}
+	return &types.Block{
+		Time:         old.Time,
+		Hash:         old.Hash,
+		ParentHash:   old.ParentHash,
+		UncleHash:    old.UncleHash,
+		Transactions: old.Transactions,
+	}, nil
+}
+
+// brings a database in the baseline layout to the layout of version 0.
+// blocks move under blockPrefix with their heights worked out by following
+// the parent links from genesis, and the longest branch becomes the
+// canonical chain. the blocks never touched the state, so they all get the
+// genesis state and are not re-executed by VerifyChain. their hashes were
+// taken over gob and are kept. everything is written in one batch, so an
+// interrupted run leaves the baseline layout to start over from. it has to
+// run after the genesis state is set up
+func (chain *BlockChain) convertBaseline() error {
+	var (
+		db       = chain.Database
+		blocks   = make(map[common.Hash]*types.Block)
+		children = make(map[common.Hash][]*types.Block)
+		keys     [][]byte
+	)
+	iter := db.NewIterator(nil)
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		keys = append(keys, append([]byte{}, key...))
+		b, err := decodeBaselineBlock(iter.Value())
+		if err != nil || b.Hash != common.Hash(key) {
+			slog.Warn("dropping unreadable baseline block", "key", common.Hash(key).Hex(), "err", err)
+			continue
+		}
+		blocks[b.Hash] = b
+		children[b.ParentHash] = append(children[b.ParentHash], b)
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	slog.Info("converting baseline database", "blocks", len(blocks))
+
+	var (
+		batch     = db.NewBatch()
+		head      *types.Block
+		converted = 0
+		queue     = children[GenesisParentHash]
+		heights   = map[common.Hash]uint64{GenesisParentHash: 0}
+	)
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		b.Height = heights[b.ParentHash] + 1
+		heights[b.Hash] = b.Height
+		for _, tx := range b.Transactions {
+			if tx.Hash == (common.Hash{}) {
+				tx.Hash = tx.ComputeHash()
+			}
+		}
+		b.TxHash = types.DeriveTxHash(b.Transactions)
+		b.StateRoot = chain.genesisRoot
+		b.GasLimit = chain.nextGasLimit(nil)
+		b.BaseFee = CalcBaseFee(chain.Config.FeeMarket, nil)
+		if err := batch.Put(blockKey(b.Hash), b.BytesStream()); err != nil {
+			return err
+		}
+		if head == nil || b.Height > head.Height {
+			head = b
+		}
+		converted++
+		queue = append(queue, children[b.Hash]...)
+	}
+	for b := head; b != nil; b = blocks[b.ParentHash] {
+		if err := batch.Put(canonicalKey(b.Height), b.Hash.Bytes()); err != nil {
+			return err
+		}
+	}
+	if head != nil {
+		if err := batch.Put(headBlockKey, head.Hash.Bytes()); err != nil {
+			return err
+		}
+		if err := batch.Put(baselineKey, encodeHeight(head.Height)); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if dropped := len(blocks) - converted; dropped > 0 {
+		slog.Warn("dropped baseline blocks that do not link to genesis", "count", dropped)
+	}
+	if head != nil {
+		slog.Info("converted baseline database", "blocks", converted, "head", head.Height)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/leveldb"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// a transaction as the first release stored it
+type baselineTx struct {
+	Time      int64
+	Fee       uint64
+	Sender    common.Address
+	Recipient common.Address
+	Amount    int64
+	Hash      common.Hash
+}
+
+// a block as the first release stored it, with its transactions
+type baselineTestBlock struct {
+	Time         int64
+	Hash         common.Hash
+	ParentHash   common.Hash
+	Transactions []*baselineTx
+}
+
+// writes a database in the baseline layout holding a chain of n blocks and
+// returns their hashes from the first block up
+func writeBaselineDatabase(t *testing.T, dir string, n int) []common.Hash {
+	t.Helper()
+	db, err := leveldb.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		hashes []common.Hash
+		parent = GenesisParentHash
+	)
+	for i := 0; i < n; i++ {
+		b := &baselineTestBlock{
+			Time:       int64(1000 + i),
+			Hash:       common.SHA256([]byte{byte(i)}),
+			ParentHash: parent,
+			Transactions: []*baselineTx{
+				{Time: int64(1000 + i), Sender: common.Address{1}, Recipient: common.Address{2}, Amount: int64(i)},
+			},
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Put(b.Hash.Bytes(), buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, b.Hash)
+		parent = b.Hash
+	}
+	return hashes
+}
+
+func TestConvertBaseline(t *testing.T) {
+	setupTestWallet(t)
+	hashes := writeBaselineDatabase(t, "db", 3)
+
+	chain := newTestChain(t, "db")
+	if chain.CurrentHeight() != 3 {
+		t.Fatalf("head at %d, want 3", chain.CurrentHeight())
+	}
+	for i, hash := range hashes {
+		b := chain.GetBlockByHeight(uint64(i + 1))
+		if b == nil || b.Hash != hash {
+			t.Fatalf("canonical block %d is %v, want %x", i+1, b, hash)
+		}
+		// the migrations that follow the conversion index the old transactions
+		if _, _, err := chain.GetTransaction(b.Transactions[0].Hash); err != nil {
+			t.Fatalf("transaction of block %d is not indexed: %v", i+1, err)
+		}
+	}
+	if got := chain.baselineHeight(); got != 3 {
+		t.Fatalf("baseline marker at %d, want 3", got)
+	}
+	data, err := chain.Database.Get(schemaVersionKey)
+	if err != nil || binary.BigEndian.Uint64(data) != SchemaVersion {
+		t.Fatalf("schema version %x, want %d", data, SchemaVersion)
+	}
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the converted chain takes new blocks on top
+	insertTestBlock(t, chain)
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaTooNew(t *testing.T) {
+	dir := t.TempDir()
+	db, err := leveldb.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put(schemaVersionKey, encodeHeight(SchemaVersion+1))
+	db.Close()
+
+	if _, err := NewChainWithConfig(params.DefaultChainConfig, &Options{DataDir: dir}); err == nil {
+		t.Fatal("opened a database with a newer schema")
+	}
+}
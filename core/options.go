@@ -0,0 +1,108 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import "path/filepath"
+
+// Options are settings local to this node. unlike the chain config they do
+// not change which blocks are valid, so nodes on one network can differ.
+type Options struct {
+	// keep an index of every canonical transaction sent or received by each
+	// address. turning it on for a database that ran without it indexes the
+	// whole chain when the database is opened
+	IndexAddresses bool
+
+	// number of entries kept by each in-memory cache. zero uses the default
+	HeaderCacheSize   int // block headers, a block without its transactions
+	BodyCacheSize     int // block transactions
+	ReceiptsCacheSize int // block receipts
+	StateCacheSize    int // account states by state root
+
+	// archive keeps every state the chain ever had, pruned only the states
+	// of the last StateHistory blocks, of the finalized block and of the
+	// checkpoint blocks every StateCheckpoints heights. empty means archive.
+	// a database that was pruned cannot go back to archive
+	GCMode           GCMode
+	StateHistory     uint64 // zero uses DefaultStateHistory
+	StateCheckpoints uint64 // zero uses DefaultStateCheckpoints
+
+	// directory of the chain database. empty means ChainDiskPath
+	DataDir string
+
+	// directory of the freezer that finalized blocks and receipts are moved
+	// into. empty means an "ancient" folder inside the chain database
+	AncientDir string
+
+	// open the chain database without writing to it, so tools can read the
+	// database of a running node. the chain is seen as it was when opened
+	// and everything that would change it fails with ErrReadOnlyDatabase
+	ReadOnly bool
+}
+
+// how a node treats historical state
+type GCMode string
+
+const (
+	GCModeArchive GCMode = "archive"
+	GCModePruned  GCMode = "pruned"
+)
+
+const (
+	DefaultHeaderCacheSize   = 1024
+	DefaultBodyCacheSize     = 256
+	DefaultReceiptsCacheSize = 256
+	DefaultStateCacheSize    = 16
+
+	DefaultStateHistory     = 128
+	DefaultStateCheckpoints = 1024
+)
+
+var DefaultOptions = &Options{}
+
+// returns the directory of the chain database
+func (opts *Options) dataDir() string {
+	if opts.DataDir == "" {
+		return ChainDiskPath
+	}
+	return opts.DataDir
+}
+
+// returns the directory of the freezer
+func (opts *Options) ancientDir() string {
+	if opts.AncientDir == "" {
+		return filepath.Join(opts.dataDir(), "ancient")
+	}
+	return opts.AncientDir
+}
+
+// returns size, or fallback when size is not set
+func cacheSize(size, fallback int) int {
+	if size <= 0 {
+		return fallback
+	}
+	return size
+}
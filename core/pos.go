@@ -0,0 +1,198 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/common/lru"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+var (
+	ErrNoSigner = errors.New("consensus engine has no signer authorized")
+)
+
+// ProofOfStake picks block proposers from the validators staked in the state,
+// weighted by stake and seeded by the randao mix
+type ProofOfStake struct {
+	pool   *ValidatorPool
+	signer *Validator
+
+	lock    sync.Mutex
+	commits *lru.Cache[common.Hash, randaoCommits]
+	// epoch anchors of recent blocks, see epochAnchor
+	anchors *lru.Cache[common.Hash, common.Hash]
+}
+
+func NewProofOfStake(pool *ValidatorPool) *ProofOfStake {
+	return &ProofOfStake{
+		pool:    pool,
+		commits: lru.New[common.Hash, randaoCommits](randaoCacheSize),
+		anchors: lru.New[common.Hash, common.Hash](randaoCacheSize),
+	}
+}
+
+// sets the validator used to prepare and seal local blocks
+func (pos *ProofOfStake) Authorize(v *Validator) {
+	pos.signer = v
+}
+
+func (pos *ProofOfStake) Author(b *types.Block) (common.Address, error) {
+	return b.Proposer, nil
+}
+
+// the block has to be signed by its proposer, which has to be the one the
+// schedule of the block's epoch names
+func (pos *ProofOfStake) VerifyHeader(chain consensus.ChainReader, b *types.Block) error {
+	if wallet.PubKeyToAddress(b.PublicKey) != b.Proposer ||
+		!wallet.VerifySignature(b.PublicKey, b.SealHash(), b.Signature) {
+		return ErrInvalidSeal
+	}
+	t, err := pos.transition(chain, b)
+	if err != nil {
+		return err
+	}
+	if t.proposerAt(b.Height) != b.Proposer {
+		return ErrInvalidProposer
+	}
+	validator := t.validator(b.Proposer)
+	if validator == nil {
+		return ErrRandaoUnknownSigner
+	}
+	commit, err := pos.randaoCommit(chain, b.ParentHash, validator)
+	if err != nil {
+		return err
+	}
+	return verifyRandao(chain, b, commit)
+}
+
+// works out the validators and schedule of the epoch b belongs to. every
+// block of the epoch on b's branch gets the same: the set is read from the
+// state b builds on, which holds it from the epoch's first block on, and the
+// schedule is seeded once, by the randao mix of the block the epoch's first
+// block builds on
+func (pos *ProofOfStake) transition(chain consensus.ChainReader, b *types.Block) (*epochTransition, error) {
+	epoch := pos.pool.EpochOf(b.Height)
+	anchor, err := pos.epochAnchor(chain, b, pos.pool.epochStart(epoch))
+	if err != nil {
+		return nil, err
+	}
+	seed := GenesisParentHash
+	if anchor != GenesisParentHash {
+		parent := chain.GetBlock(anchor)
+		if parent == nil {
+			return nil, ErrUnknownParent
+		}
+		seed = parent.RandaoMix
+	}
+	return pos.pool.prepareTransition(epoch, anchor, seed.Bytes(), func() ([]*ActiveValidator, error) {
+		statedb, err := chain.ParentState(b)
+		if err != nil {
+			return nil, err
+		}
+		return stakedValidators(statedb, pos.pool.startsEpoch(b.Height))
+	})
+}
+
+// returns the hash of the block the first block of b's epoch builds on,
+// given the height the epoch starts at. the answers for the blocks walked
+// through are cached, so the walk back is only done once per epoch
+func (pos *ProofOfStake) epochAnchor(chain consensus.ChainReader, b *types.Block, start uint64) (common.Hash, error) {
+	// hash is the parent of a block at height, which is in b's epoch
+	hash, height := b.ParentHash, b.Height
+	var walked []common.Hash
+	for height > start {
+		if anchor, ok := pos.anchors.Get(hash); ok {
+			hash = anchor
+			break
+		}
+		parent := chain.GetBlock(hash)
+		if parent == nil {
+			return common.Hash{}, ErrUnknownParent
+		}
+		walked = append(walked, hash)
+		hash, height = parent.ParentHash, parent.Height
+	}
+	for _, h := range walked {
+		pos.anchors.Add(h, hash)
+	}
+	return hash, nil
+}
+
+// fills in the randao fields for the authorized signer, which has to be the
+// proposer scheduled for the block
+func (pos *ProofOfStake) Prepare(chain consensus.ChainReader, b *types.Block) error {
+	if pos.signer == nil {
+		return ErrNoSigner
+	}
+	t, err := pos.transition(chain, b)
+	if err != nil {
+		return err
+	}
+	if t.proposerAt(b.Height) != pos.signer.Wallet.Address {
+		return ErrInvalidProposer
+	}
+	validator := t.validator(pos.signer.Wallet.Address)
+	if validator == nil {
+		return ErrRandaoUnknownSigner
+	}
+	commit, err := pos.randaoCommit(chain, b.ParentHash, validator)
+	if err != nil {
+		return err
+	}
+	return prepareRandao(chain, b, pos.signer, commit)
+}
+
+// caches the randao commitments the block leaves behind for its children
+func (pos *ProofOfStake) Finalize(chain consensus.ChainReader, b *types.Block) error {
+	_, err := pos.randaoCommits(chain, b.Hash)
+	return err
+}
+
+// signs the block with the authorized validator's wallet, which has to be
+// the proposer Prepare filled in
+func (pos *ProofOfStake) Seal(chain consensus.ChainReader, b *types.Block) error {
+	if pos.signer == nil {
+		return ErrNoSigner
+	}
+	if b.Proposer != pos.signer.Wallet.Address {
+		return ErrInvalidProposer
+	}
+	b.PublicKey = pos.signer.Wallet.PublicKeyBytes()
+	sig, err := pos.signer.Wallet.Sign(b.SealHash())
+	if err != nil {
+		return err
+	}
+	b.Signature = sig
+	b.Hash = b.ComputeHash()
+	return nil
+}
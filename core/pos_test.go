@@ -0,0 +1,230 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// opens a proof of stake chain in dir with short epochs that starts with n
+// validators of fresh wallets, the first staking the most, and the balances
+// of alloc
+func newTestStakeChain(t *testing.T, dir string, n int, alloc map[common.Address]uint64) (*BlockChain, []*Validator) {
+	t.Helper()
+	config := *params.DefaultChainConfig
+	config.EpochLength = 4
+	config.Alloc = alloc
+	var validators []*Validator
+	for i := 0; i < n; i++ {
+		w, err := wallet.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := newValidator(w)
+		config.Validators = append(config.Validators, v.GenesisValidator(uint64(n-i)*params.Nex))
+		validators = append(validators, v)
+	}
+	chain, err := NewChainWithConfig(&config, &Options{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	return chain, validators
+}
+
+// builds, seals and inserts the next block holding txs as whichever of
+// validators is scheduled to propose it
+func insertScheduledBlock(t *testing.T, chain *BlockChain, validators []*Validator, time int64, txs ...*types.Transaction) *types.Block {
+	t.Helper()
+	pos := chain.Engine.(*ProofOfStake)
+	for _, v := range validators {
+		pos.Authorize(v)
+		b, err := chain.BuildBlock(time, txs)
+		if errors.Is(err, ErrInvalidProposer) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pos.Seal(chain, b); err != nil {
+			t.Fatal(err)
+		}
+		if err := chain.Insert(b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	t.Fatalf("no validator is scheduled at height %d", chain.CurrentHeight()+1)
+	return nil
+}
+
+func TestPosSeal(t *testing.T) {
+	setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	insertTestBlock(t, chain)
+
+	b, err := chain.BuildBlock(time.Now().Unix()-100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Engine.VerifyHeader(chain, b); !errors.Is(err, ErrInvalidSeal) {
+		t.Fatalf("unsealed block: %v, want %v", err, ErrInvalidSeal)
+	}
+	if err := chain.Engine.Seal(chain, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.Engine.VerifyHeader(chain, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// a change after sealing breaks the signature even with a fresh hash
+	tampered := b.CopyHeader()
+	tampered.GasUsed++
+	tampered.Hash = tampered.ComputeHash()
+	if err := chain.Engine.VerifyHeader(chain, tampered); !errors.Is(err, ErrInvalidSeal) {
+		t.Fatalf("tampered block: %v, want %v", err, ErrInvalidSeal)
+	}
+
+	// a signature by anyone but the proposer does not count
+	other, err := wallet.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := b.CopyHeader()
+	forged.PublicKey = other.PublicKeyBytes()
+	if forged.Signature, err = other.Sign(forged.SealHash()); err != nil {
+		t.Fatal(err)
+	}
+	forged.Hash = forged.ComputeHash()
+	if err := chain.Engine.VerifyHeader(chain, forged); !errors.Is(err, ErrInvalidSeal) {
+		t.Fatalf("block signed by another wallet: %v, want %v", err, ErrInvalidSeal)
+	}
+
+	if err := chain.Insert(b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// every block of an epoch is proposed by the validator the epoch's schedule
+// names, not by one picked with a seed that changes from block to block
+func TestProposerSchedule(t *testing.T) {
+	setupTestWallet(t)
+	chain, validators := newTestStakeChain(t, "db", 3, nil)
+	base := time.Now().Unix() - 1000
+	for i := int64(0); i < 14; i++ {
+		insertScheduledBlock(t, chain, validators, base+i)
+	}
+
+	proposers := make(map[string]bool)
+	for height := uint64(1); height <= chain.CurrentHeight(); height++ {
+		b := chain.GetBlockByHeight(height)
+		schedule, err := chain.ProposerSchedule(chain.Validators.EpochOf(height))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := schedule[height%uint64(len(schedule))]; b.Proposer != want {
+			t.Fatalf("block %d proposed by %x, the schedule says %x", height, b.Proposer, want)
+		}
+		proposers[b.Proposer.Hex()] = true
+	}
+	if len(proposers) < 2 {
+		t.Fatal("a single validator proposed every block")
+	}
+}
+
+// returns whether addr is in the validator set of the head's epoch
+func isActiveValidator(t *testing.T, chain *BlockChain, addr common.Address) bool {
+	t.Helper()
+	active, err := chain.ActiveValidators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range active {
+		if v.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// a stake is held as pending in the state, survives a restart and only
+// joins the validator set at the next epoch, and an unstake pays it back at
+// the epoch after
+func TestStakeEpochBoundary(t *testing.T) {
+	t.Chdir(t.TempDir())
+	joinerWallet, err := wallet.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	joiner := newValidator(joinerWallet)
+	chain, validators := newTestStakeChain(t, "db", 1, map[common.Address]uint64{joinerWallet.Address: 100 * params.Nex})
+	validators = append(validators, joiner)
+	base := time.Now().Unix() - 1000
+
+	stakeTx := types.NewStakeTx(0, base, joinerWallet.Address, int64(5*params.Nex), joiner.RandaoCommit, params.TxGasStake, 2000, 1)
+	if err := SignTx(stakeTx, joinerWallet); err != nil {
+		t.Fatal(err)
+	}
+	insertScheduledBlock(t, chain, validators, base+1, stakeTx)
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := statedb.GetStake(joinerWallet.Address); got.Pending != 5*params.Nex || got.Active != 0 {
+		t.Fatalf("stake after staking is %+v", got)
+	}
+
+	// the pending stake is chain state, a restarted node still has it
+	config := chain.Config
+	chain.Close()
+	if chain, err = NewChainWithConfig(config, &Options{DataDir: "db"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+
+	for height := uint64(2); height < 4; height++ {
+		insertScheduledBlock(t, chain, validators, base+int64(height))
+		if isActiveValidator(t, chain, joinerWallet.Address) {
+			t.Fatalf("stake is active at height %d, before the epoch it was made in ended", height)
+		}
+	}
+	insertScheduledBlock(t, chain, validators, base+4)
+	if !isActiveValidator(t, chain, joinerWallet.Address) {
+		t.Fatal("stake did not join the validator set at the epoch boundary")
+	}
+
+	unstakeTx := types.NewUnstakeTx(1, base, joinerWallet.Address, params.TxGasStake, 2000, 1)
+	if err := SignTx(unstakeTx, joinerWallet); err != nil {
+		t.Fatal(err)
+	}
+	insertScheduledBlock(t, chain, validators, base+5, unstakeTx)
+	for height := uint64(6); height < 8; height++ {
+		insertScheduledBlock(t, chain, validators, base+int64(height))
+		if !isActiveValidator(t, chain, joinerWallet.Address) {
+			t.Fatalf("exiting validator left the set at height %d, before the epoch ended", height)
+		}
+	}
+	insertScheduledBlock(t, chain, validators, base+8)
+	if isActiveValidator(t, chain, joinerWallet.Address) {
+		t.Fatal("exiting validator is still in the set of the next epoch")
+	}
+	statedb, err = chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := statedb.GetStake(joinerWallet.Address); got != (state.Stake{}) {
+		t.Fatalf("stake after it was paid back is %+v", got)
+	}
+	if balance := statedb.GetBalance(joinerWallet.Address); balance < 99*params.Nex {
+		t.Fatalf("balance %d after the stake was paid back", balance)
+	}
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+}
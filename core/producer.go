@@ -0,0 +1,83 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// builds a block on top of the head out of candidates, taken in order, and
+// stops once the block has no room for another transaction. candidates that
+// would fail or do not fit in the gas left are skipped. the block is prepared
+// by the engine and assembled, it only has to be sealed before insertion.
+func (chain *BlockChain) BuildBlock(time int64, candidates []*types.Transaction) (*types.Block, error) {
+	parentHash, height := GenesisParentHash, uint64(1)
+	parent, err := chain.Last()
+	if err == nil {
+		parentHash, height = parent.Hash, parent.Height+1
+	} else {
+		parent = nil
+	}
+
+	b := types.NewBlock(height, time, parentHash, nil)
+	if err := chain.Engine.Prepare(chain, b); err != nil {
+		return nil, err
+	}
+	b.BaseFee = CalcBaseFee(chain.Config.FeeMarket, parent)
+	b.GasLimit = chain.nextGasLimit(parent)
+
+	statedb, err := chain.ParentState(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := chain.beginBlock(statedb, b); err != nil {
+		return nil, err
+	}
+	gasLeft := b.GasLimit
+	for _, tx := range candidates {
+		if gasLeft < params.TxGasTransfer {
+			break
+		}
+		if tx.GasLimit > gasLeft {
+			continue
+		}
+		attempt := statedb.Copy()
+		receipt, err := applyTransaction(attempt, tx, b.BaseFee)
+		if err != nil {
+			continue
+		}
+		statedb = attempt
+		gasLeft -= receipt.GasUsed
+		b.Transactions = append(b.Transactions, tx)
+	}
+
+	if err := chain.Assemble(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
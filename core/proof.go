@@ -1 +1,167 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
 package core
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+var (
+	ErrInvalidDifficulty = errors.New("block difficulty does not match the retarget rule")
+	ErrInvalidPow        = errors.New("block hash does not meet the difficulty target")
+	ErrBlockTimeTooEarly = errors.New("block time is before its parent")
+	ErrFutureBlock       = errors.New("block time is too far in the future")
+)
+
+// how far ahead of the local clock a block's time may be. without a bound a
+// miner could stamp blocks far ahead to pull the difficulty down
+const allowedFutureBlockTime = 15 * time.Second
+
+// 2^256, every target is this divided by the difficulty
+var two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// ProofOfWork is a nakamoto style engine meant for test networks. blocks are
+// sealed by searching for a nonce that brings the block hash under the target
+// and the difficulty moves each block to keep block times near the configured
+// target.
+type ProofOfWork struct {
+	config   *params.PowConfig
+	coinbase common.Address
+}
+
+func NewProofOfWork(config *params.PowConfig) *ProofOfWork {
+	if config == nil {
+		config = params.DefaultPowConfig
+	}
+	return &ProofOfWork{config: config}
+}
+
+// sets the address credited as the author of locally sealed blocks
+func (pow *ProofOfWork) SetCoinbase(addr common.Address) {
+	pow.coinbase = addr
+}
+
+func (pow *ProofOfWork) Author(b *types.Block) (common.Address, error) {
+	return b.Proposer, nil
+}
+
+func (pow *ProofOfWork) VerifyHeader(chain consensus.ChainReader, b *types.Block) error {
+	if b.Time > time.Now().Add(allowedFutureBlockTime).Unix() {
+		return ErrFutureBlock
+	}
+	parent := chain.GetBlock(b.ParentHash)
+	if parent != nil && b.Time < parent.Time {
+		return ErrBlockTimeTooEarly
+	}
+	if b.Difficulty != pow.CalcDifficulty(parent, b.Time) {
+		return ErrInvalidDifficulty
+	}
+	if !meetsTarget(b.Hash, b.Difficulty) {
+		return ErrInvalidPow
+	}
+	return nil
+}
+
+func (pow *ProofOfWork) Prepare(chain consensus.ChainReader, b *types.Block) error {
+	var parent *types.Block
+	if b.ParentHash != GenesisParentHash {
+		if parent = chain.GetBlock(b.ParentHash); parent == nil {
+			return ErrUnknownParent
+		}
+	}
+	b.Proposer = pow.coinbase
+	b.Difficulty = pow.CalcDifficulty(parent, b.Time)
+	return nil
+}
+
+func (pow *ProofOfWork) Finalize(chain consensus.ChainReader, b *types.Block) error {
+	return nil
+}
+
+// searches nonces until the block hash is below the target
+func (pow *ProofOfWork) Seal(chain consensus.ChainReader, b *types.Block) error {
+	for nonce := uint64(0); ; nonce++ {
+		b.Nonce = nonce
+		hash := b.ComputeHash()
+		if meetsTarget(hash, b.Difficulty) {
+			b.Hash = hash
+			return nil
+		}
+	}
+}
+
+// returns the difficulty a block built on parent at the given time must have.
+// blocks that come quicker than the target time push difficulty up, slower
+// ones pull it down, by at most parent/BoundDivisor per block.
+func (pow *ProofOfWork) CalcDifficulty(parent *types.Block, time int64) uint64 {
+	if parent == nil {
+		return pow.config.GenesisDifficulty
+	}
+
+	step := parent.Difficulty / pow.config.BoundDivisor
+	if step == 0 {
+		step = 1
+	}
+
+	elapsed := uint64(0)
+	if time > parent.Time {
+		elapsed = uint64(time - parent.Time)
+	}
+
+	var difficulty uint64
+	switch {
+	case elapsed < pow.config.BlockTime:
+		difficulty = parent.Difficulty + step
+	case elapsed > pow.config.BlockTime:
+		if parent.Difficulty > step {
+			difficulty = parent.Difficulty - step
+		}
+	default:
+		difficulty = parent.Difficulty
+	}
+
+	if difficulty < pow.config.MinimumDifficulty {
+		difficulty = pow.config.MinimumDifficulty
+	}
+	return difficulty
+}
+
+// reports whether hash is at or below 2^256 / difficulty
+func meetsTarget(hash common.Hash, difficulty uint64) bool {
+	if difficulty == 0 {
+		return false
+	}
+	target := new(big.Int).Div(two256, new(big.Int).SetUint64(difficulty))
+	return new(big.Int).SetBytes(hash.Bytes()).Cmp(target) <= 0
+}
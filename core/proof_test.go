@@ -0,0 +1,90 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+func TestPowCalcDifficulty(t *testing.T) {
+	pow := NewProofOfWork(&params.PowConfig{BlockTime: 10, GenesisDifficulty: 100, MinimumDifficulty: 50, BoundDivisor: 10})
+	parent := &types.Block{Time: 1000, Difficulty: 100}
+	tests := []struct {
+		name string
+		time int64
+		want uint64
+	}{
+		{"quick block", 1005, 110},
+		{"on time", 1010, 100},
+		{"slow block", 1030, 90},
+		{"before its parent", 990, 110},
+	}
+	for _, tt := range tests {
+		if got := pow.CalcDifficulty(parent, tt.time); got != tt.want {
+			t.Errorf("%s: difficulty %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if got := pow.CalcDifficulty(nil, 0); got != 100 {
+		t.Errorf("genesis difficulty %d, want 100", got)
+	}
+	low := &types.Block{Time: 1000, Difficulty: 52}
+	if got := pow.CalcDifficulty(low, 2000); got != 50 {
+		t.Errorf("difficulty fell to %d, below the minimum", got)
+	}
+}
+
+func TestPowVerifyHeader(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestPowChain(t, "db", w.Address)
+	base := time.Now().Unix() - 1000
+	parent := insertTestBlockAt(t, chain, base)
+
+	seal := func(b *types.Block) *types.Block {
+		if err := chain.Engine.Seal(chain, b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	build := func(time int64) *types.Block {
+		b, err := chain.BuildBlock(time, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	good := seal(build(base + 10))
+	if err := chain.Engine.VerifyHeader(chain, good); err != nil {
+		t.Fatal(err)
+	}
+	easier := build(base + 10)
+	easier.Difficulty--
+	tests := []struct {
+		name string
+		b    *types.Block
+		err  error
+	}{
+		{"future block", build(time.Now().Add(time.Hour).Unix()), ErrFutureBlock},
+		{"before its parent", build(parent.Time - 1), ErrBlockTimeTooEarly},
+		{"wrong difficulty", seal(easier), ErrInvalidDifficulty},
+	}
+	for _, tt := range tests {
+		if err := chain.Engine.VerifyHeader(chain, tt.b); !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+
+	// a nonce whose hash misses the target
+	missed := build(base + 10)
+	for missed.Nonce = 0; ; missed.Nonce++ {
+		if missed.Hash = missed.ComputeHash(); !meetsTarget(missed.Hash, missed.Difficulty) {
+			break
+		}
+	}
+	if err := chain.Engine.VerifyHeader(chain, missed); !errors.Is(err, ErrInvalidPow) {
+		t.Fatalf("hash above the target: %v, want %v", err, ErrInvalidPow)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// tracks what a pruned node has to keep
+type statePruner struct {
+	history     uint64
+	checkpoints uint64
+	lastPruned  uint64
+
+	// state roots of recently inserted blocks on every branch, by height,
+	// so a side branch inside the window can still be extended
+	recent map[common.Hash]uint64
+
+	// the sweep runs in the background, commits hold the guard so it never
+	// deletes what they write
+	guard   state.PruneGuard
+	running atomic.Bool
+	wg      sync.WaitGroup
+}
+
+// checks the requested gc mode against the one the database was last opened
+// with. going from archive to pruned is always allowed, going back is only
+// allowed while the chain is empty since the pruned states are gone for good
+func (chain *BlockChain) setupGCMode() error {
+	if chain.Options.ReadOnly {
+		return nil
+	}
+	mode := chain.Options.GCMode
+	if mode == "" {
+		mode = GCModeArchive
+	}
+	if mode != GCModeArchive && mode != GCModePruned {
+		return ErrGCMode
+	}
+
+	if stored, err := chain.Database.Get(gcModeKey); err == nil {
+		if GCMode(stored) == GCModePruned && mode == GCModeArchive && chain.CurrentBlock() != nil {
+			return ErrPrunedDatabase
+		}
+		if GCMode(stored) == GCModeArchive && mode == GCModePruned && chain.CurrentBlock() != nil {
+			slog.Warn("switching archive database to pruned, historical state will be deleted")
+		}
+	}
+	if err := chain.Database.Put(gcModeKey, []byte(mode)); err != nil {
+		return err
+	}
+
+	if mode == GCModePruned {
+		history := chain.Options.StateHistory
+		if history == 0 {
+			history = DefaultStateHistory
+		}
+		checkpoints := chain.Options.StateCheckpoints
+		if checkpoints == 0 {
+			checkpoints = DefaultStateCheckpoints
+		}
+		chain.pruner = &statePruner{
+			history:     history,
+			checkpoints: checkpoints,
+			lastPruned:  chain.CurrentHeight(),
+			recent:      make(map[common.Hash]uint64),
+		}
+	}
+	return nil
+}
+
+// records the state of a newly inserted block and starts a prune in the
+// background once the head has moved a whole window past the last one, so
+// at most twice the window is kept on disk. the caller must hold chainmu
+func (chain *BlockChain) maybePrune(b *types.Block) error {
+	if chain.pruner == nil {
+		return nil
+	}
+	chain.pruner.recent[b.StateRoot] = b.Height
+	if chain.CurrentHeight() < chain.pruner.lastPruned+chain.pruner.history {
+		return nil
+	}
+	if !chain.pruner.running.CompareAndSwap(false, true) {
+		// the last prune is still sweeping, a later block starts the next
+		return nil
+	}
+	keep, head := chain.pruneKeep(), chain.CurrentHeight()
+	chain.pruner.lastPruned = head
+	chain.pruner.wg.Add(1)
+	go func() {
+		defer chain.pruner.wg.Done()
+		defer chain.pruner.running.Store(false)
+		if err := chain.pruneState(keep, head); err != nil {
+			slog.Error("failed to prune state", "err", err)
+		}
+	}()
+	return nil
+}
+
+// deletes all state except that of the last StateHistory canonical blocks,
+// recent side blocks, the finalized block, the checkpoints and the genesis
+// allocation
+func (chain *BlockChain) PruneState() error {
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	if chain.Options.ReadOnly {
+		return ErrReadOnlyDatabase
+	}
+	if chain.pruner == nil {
+		return ErrArchiveMode
+	}
+	chain.pruner.wg.Wait()
+	head := chain.CurrentHeight()
+	chain.pruner.lastPruned = head
+	return chain.pruneState(chain.pruneKeep(), head)
+}
+
+// returns the state roots a prune keeps: those of the last StateHistory
+// canonical blocks, recent side blocks, the finalized block, the canonical
+// blocks at every StateCheckpoints height and the genesis allocation. the
+// caller must hold chainmu
+func (chain *BlockChain) pruneKeep() map[common.Hash]struct{} {
+	head := chain.CurrentHeight()
+	oldest := uint64(1)
+	if head > chain.pruner.history {
+		oldest = head - chain.pruner.history + 1
+	}
+
+	keep := map[common.Hash]struct{}{chain.genesisRoot: {}}
+	for height := oldest; height <= head; height++ {
+		if b := chain.GetHeaderByHeight(height); b != nil {
+			keep[b.StateRoot] = struct{}{}
+		}
+	}
+	// checkpoints are taken from the canonical chain up to the head, so one
+	// is still there when finality catches up with it
+	for height := chain.pruner.checkpoints; height < oldest; height += chain.pruner.checkpoints {
+		if b := chain.GetHeaderByHeight(height); b != nil {
+			keep[b.StateRoot] = struct{}{}
+		}
+	}
+	if finalized := chain.FinalizedBlock(); finalized != nil {
+		keep[finalized.StateRoot] = struct{}{}
+	}
+	for root, height := range chain.pruner.recent {
+		if height < oldest {
+			delete(chain.pruner.recent, root)
+			continue
+		}
+		keep[root] = struct{}{}
+	}
+	return keep
+}
+
+// deletes every state not in keep. it can run next to inserts, as those
+// commit their state through commitState
+func (chain *BlockChain) pruneState(keep map[common.Hash]struct{}, head uint64) error {
+	roots, nodes, err := state.Prune(chain.Database, keep, &chain.pruner.guard)
+	if err != nil {
+		return err
+	}
+	chain.stateCache.Purge()
+	slog.Info("pruned state", "head", head, "kept", len(keep), "roots", roots, "nodes", nodes)
+	return nil
+}
+
+// keeps a prune running in the background from deleting anything until
+// the returned func is called. a block holds it from opening its parent
+// state until its own state is committed: the parent is either kept, or it
+// is deleted before any trie node is, and the nodes the new state shares
+// with it are marked with the new state. the cache may still hand out a
+// parent whose root was deleted, so that is checked through StateAt
+func (chain *BlockChain) lockState() func() {
+	if chain.pruner == nil {
+		return func() {}
+	}
+	chain.pruner.guard.Lock()
+	return chain.pruner.guard.Unlock
+}
+
+// writes statedb out and records it with a prune running in the
+// background, so the prune keeps it. the caller must hold lockState
+func (chain *BlockChain) commitState(statedb *state.StateDB) (common.Hash, error) {
+	root, err := statedb.Commit()
+	if err != nil || chain.pruner == nil {
+		return root, err
+	}
+	chain.pruner.guard.Committed(root)
+	return root, nil
+}
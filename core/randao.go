@@ -0,0 +1,136 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/consensus"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// randomness is accumulated RANDAO style: every validator commits to the end of
+// a hash chain when it joins and each block it proposes reveals the next
+// preimage. the reveal is fixed by the commitment, so the only thing a proposer
+// can do to bias the mix is to not propose at all.
+
+// returns the randao mix the given block builds on
+func parentRandaoMix(chain consensus.ChainReader, b *types.Block) (common.Hash, error) {
+	if b.ParentHash == GenesisParentHash {
+		return GenesisParentHash, nil
+	}
+	parent := chain.GetBlock(b.ParentHash)
+	if parent == nil {
+		return common.Hash{}, ErrUnknownParent
+	}
+	return parent.RandaoMix, nil
+}
+
+// number of blocks whose randao commitments are kept in memory
+const randaoCacheSize = 128
+
+// the commitment each validator's next reveal has to open as of a block.
+// validators that have not proposed on the branch are missing and still
+// have the commitment they registered with
+type randaoCommits map[common.Address]common.Hash
+
+// returns the commitments as of the block with the given hash. they are
+// worked out from the nearest cached ancestor, walking back to the first
+// block if needed, so they follow the branch and survive restarts
+func (pos *ProofOfStake) randaoCommits(chain consensus.ChainReader, hash common.Hash) (randaoCommits, error) {
+	pos.lock.Lock()
+	defer pos.lock.Unlock()
+
+	var pending []*types.Block
+	commits := randaoCommits{}
+	for hash != GenesisParentHash {
+		if cached, ok := pos.commits.Get(hash); ok {
+			commits = cached
+			break
+		}
+		b := chain.GetBlock(hash)
+		if b == nil {
+			return nil, ErrUnknownParent
+		}
+		pending = append(pending, b)
+		hash = b.ParentHash
+	}
+	for i := len(pending) - 1; i >= 0; i-- {
+		next := make(randaoCommits, len(commits)+1)
+		for addr, commit := range commits {
+			next[addr] = commit
+		}
+		next[pending[i].Proposer] = pending[i].RandaoReveal
+		pos.commits.Add(pending[i].Hash, next)
+		commits = next
+	}
+	return commits, nil
+}
+
+// returns the commitment v's reveal has to open in a block on top of parent
+func (pos *ProofOfStake) randaoCommit(chain consensus.ChainReader, parent common.Hash, v *ActiveValidator) (common.Hash, error) {
+	commits, err := pos.randaoCommits(chain, parent)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if commit, ok := commits[v.Address]; ok {
+		return commit, nil
+	}
+	return v.RandaoCommit, nil
+}
+
+// fills in the proposer and randao fields of a block proposed by v, whose
+// current commitment is commit
+func prepareRandao(chain consensus.ChainReader, b *types.Block, v *Validator, commit common.Hash) error {
+	parentMix, err := parentRandaoMix(chain, b)
+	if err != nil {
+		return err
+	}
+	reveal, err := v.RevealFor(commit)
+	if err != nil {
+		return err
+	}
+	b.Proposer = v.Wallet.Address
+	b.RandaoReveal = reveal
+	b.RandaoMix = types.MixRandao(parentMix, reveal)
+	return nil
+}
+
+// checks that the block's reveal opens the proposer's commitment and that the
+// mix was derived from it
+func verifyRandao(chain consensus.ChainReader, b *types.Block, commit common.Hash) error {
+	if common.SHA256(b.RandaoReveal.Bytes()) != commit {
+		return ErrRandaoInvalidReveal
+	}
+	parentMix, err := parentRandaoMix(chain, b)
+	if err != nil {
+		return err
+	}
+	if types.MixRandao(parentMix, b.RandaoReveal) != b.RandaoMix {
+		return ErrRandaoInvalidMix
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"encoding/binary"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+)
+
+// key layout of the chain database. metadata keys are capitalised words and
+// every table is a single lower case letter, so iterating a table never runs
+// into a metadata key. the state tables and the snapshot live in their
+// packages and follow the same rule
+var (
+	headBlockKey      = []byte("LastBlock")      // hash of the current canonical head
+	finalizedBlockKey = []byte("FinalizedBlock") // hash of the latest finalized block
+	gcModeKey         = []byte("GCMode")         // GCMode the database was last opened with
+	schemaVersionKey  = []byte("SchemaVersion")  // layout version of the database
+	migrationKey      = []byte("Migration")      // version and cursor of an unfinished migration
+	tailBlockKey      = []byte("TailBlock")      // hash of the first block of a chain started from a snapshot
+	baselineKey       = []byte("Baseline")       // height up to which blocks were converted from the stateless baseline layout
+	addressIndexedKey = []byte("AddressIndexed") // present while the address index covers every canonical block
+	orphanSweepKey    = []byte("OrphanSweep")    // present while the trie nodes of states a rewind deleted wait to be swept
+
+	blockPrefix         = []byte("b") // blockPrefix + hash -> block
+	canonicalPrefix     = []byte("h") // canonicalPrefix + height -> hash
+	certificatePrefix   = []byte("c") // certificatePrefix + hash -> commit certificate
+	receiptsPrefix      = []byte("r") // receiptsPrefix + hash -> block receipts
+	txLookupPrefix      = []byte("l") // txLookupPrefix + tx hash -> tx location
+	addressPrefix       = []byte("a") // addressPrefix + address + height + index -> tx hash + role
+	blockHeightPrefix   = []byte("n") // blockHeightPrefix + hash -> height, for blocks moved to the freezer
+	tdPrefix            = []byte("t") // tdPrefix + hash -> total difficulty of the branch ending there
+	poaCheckpointPrefix = []byte("p") // poaCheckpointPrefix + hash -> proof of authority signer checkpoint
+)
+
+// encodes a height as big endian so keys sort in chain order
+func encodeHeight(height uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, height)
+	return enc
+}
+
+func blockKey(hash common.Hash) []byte {
+	return append(append([]byte{}, blockPrefix...), hash.Bytes()...)
+}
+
+func blockHeightKey(hash common.Hash) []byte {
+	return append(append([]byte{}, blockHeightPrefix...), hash.Bytes()...)
+}
+
+func tdKey(hash common.Hash) []byte {
+	return append(append([]byte{}, tdPrefix...), hash.Bytes()...)
+}
+
+func poaCheckpointKey(hash common.Hash) []byte {
+	return append(append([]byte{}, poaCheckpointPrefix...), hash.Bytes()...)
+}
+
+func canonicalKey(height uint64) []byte {
+	return append(append([]byte{}, canonicalPrefix...), encodeHeight(height)...)
+}
+
+func certificateKey(hash common.Hash) []byte {
+	return append(append([]byte{}, certificatePrefix...), hash.Bytes()...)
+}
+
+func receiptsKey(hash common.Hash) []byte {
+	return append(append([]byte{}, receiptsPrefix...), hash.Bytes()...)
+}
+
+func txLookupKey(hash common.Hash) []byte {
+	return append(append([]byte{}, txLookupPrefix...), hash.Bytes()...)
+}
+
+// entries of one address sort by height and then position in the block
+func addressIndexKey(addr common.Address, height uint64, index uint64) []byte {
+	key := append(append([]byte{}, addressPrefix...), addr.Bytes()...)
+	key = append(key, encodeHeight(height)...)
+	return append(key, encodeHeight(index)...)
+}
+
+func addressIndexPrefix(addr common.Address) []byte {
+	return append(append([]byte{}, addressPrefix...), addr.Bytes()...)
+}
@@ -0,0 +1,253 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"log/slog"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// rewinds the chain so the canonical block at height becomes the head. the
+// canonical blocks above it are deleted together with their receipts,
+// indexes and states in one atomic write, then a reorg event hands their
+// transactions back to the pool. the head cannot go below the finalized block
+func (chain *BlockChain) SetHead(height uint64) error {
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+
+	if finalized := chain.finalized.Load(); finalized != nil && height < finalized.Height {
+		return ErrSetHeadFinalized
+	}
+	if height >= chain.CurrentHeight() {
+		return nil
+	}
+	removed, err := chain.setHead(height)
+	if err != nil {
+		return err
+	}
+	chain.feeds.reorg.Send(ReorgEvent{OldChain: removed})
+	if head := chain.head.Load(); head != nil {
+		chain.feeds.head.Send(ChainHeadEvent{Block: head})
+	}
+	return nil
+}
+
+// does the work of SetHead without checking finality, so Repair can also
+// back out of a broken finalized block. blocks that cannot be read are
+// skipped rather than failing the rewind. it returns the removed blocks,
+// highest first. the caller must hold chainmu
+func (chain *BlockChain) setHead(height uint64) ([]*types.Block, error) {
+	if chain.Options.ReadOnly {
+		return nil, ErrReadOnlyDatabase
+	}
+	var head *types.Block
+	if height > 0 {
+		if head = chain.GetBlockByHeight(height); head == nil {
+			return nil, ErrChainCorrupt
+		}
+	}
+	batch := chain.Database.NewBatch()
+	var removed []*types.Block
+	for h := chain.CurrentHeight(); h > height; h-- {
+		hash, err := chain.Database.Get(canonicalKey(h))
+		if err != nil {
+			continue
+		}
+		if err := batch.Delete(canonicalKey(h)); err != nil {
+			return nil, err
+		}
+		b := chain.readBlock(common.Hash(hash))
+		if b == nil {
+			continue
+		}
+		if err := chain.unwindCanonical(batch, b); err != nil {
+			return nil, err
+		}
+		for _, key := range [][]byte{blockKey(b.Hash), receiptsKey(b.Hash), certificateKey(b.Hash), blockHeightKey(b.Hash), tdKey(b.Hash)} {
+			if err := batch.Delete(key); err != nil {
+				return nil, err
+			}
+		}
+		removed = append(removed, b)
+	}
+
+	// blocks that changed nothing share their state with the block below,
+	// so a state is only deleted when no block the rewind keeps uses it
+	roots := make(map[common.Hash]struct{}, len(removed))
+	for _, b := range removed {
+		if b.StateRoot != chain.genesisRoot {
+			roots[b.StateRoot] = struct{}{}
+		}
+	}
+	if err := chain.dropUsedRoots(roots, height, removed); err != nil {
+		return nil, err
+	}
+	for root := range roots {
+		if err := state.DeleteRoot(batch, root); err != nil {
+			return nil, err
+		}
+	}
+	// an archive node has no pruner to come along for the trie nodes the
+	// deleted states leave behind. the marker goes out with the rewind, so
+	// a crash before the sweep is finished has it redone on the next start
+	sweep := chain.pruner == nil && len(roots) > 0
+	if sweep {
+		if err := batch.Put(orphanSweepKey, []byte{1}); err != nil {
+			return nil, err
+		}
+	}
+
+	finalized := chain.finalized.Load()
+	moveFinalized := finalized != nil && finalized.Height > height
+	if head != nil {
+		if err := batch.Put(headBlockKey, head.Hash.Bytes()); err != nil {
+			return nil, err
+		}
+		if moveFinalized {
+			if err := batch.Put(finalizedBlockKey, head.Hash.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		batch.Delete(headBlockKey)
+		batch.Delete(finalizedBlockKey)
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+
+	// the freezer cannot join the batch. a crash before it is truncated
+	// leaves frozen blocks that are no longer canonical, which
+	// repairFreezer truncates away on the next start
+	if chain.FrozenHeight() > height {
+		if err := chain.Ancients.Truncate(height); err != nil {
+			return nil, err
+		}
+	}
+	if sweep {
+		if err := chain.sweepOrphans(); err != nil {
+			return nil, err
+		}
+	}
+	chain.head.Store(head)
+	if moveFinalized {
+		chain.finalized.Store(head)
+	}
+	chain.forgetAbove(height)
+	chain.capSnapshot(snapshotLayers)
+
+	slog.Info("rewound chain", "head", height, "removed", len(removed))
+	return removed, nil
+}
+
+// removes from roots those a block kept by a rewind to height still uses:
+// the canonical blocks up to height and every block in leveldb that is not
+// in removed. it reads every kept block, which a rewind can afford next to
+// the orphan sweep an archive node runs after it
+func (chain *BlockChain) dropUsedRoots(roots map[common.Hash]struct{}, height uint64, removed []*types.Block) error {
+	if len(roots) == 0 {
+		return nil
+	}
+	gone := make(map[common.Hash]struct{}, len(removed))
+	for _, b := range removed {
+		gone[b.Hash] = struct{}{}
+	}
+
+	// leveldb holds the canonical blocks above the freezer and the side
+	// blocks
+	iter := chain.Database.NewIterator(blockPrefix)
+	defer iter.Release()
+	for iter.Next() && len(roots) > 0 {
+		key := iter.Key()
+		if len(key) != len(blockPrefix)+common.HashLength {
+			continue
+		}
+		if _, ok := gone[common.Hash(key[len(blockPrefix):])]; ok {
+			continue
+		}
+		delete(roots, types.DecodeBlockBytesStream(iter.Value()).StateRoot)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	for h := chain.tailHeight(); h <= min(height, chain.FrozenHeight()) && len(roots) > 0; h++ {
+		header, err := chain.Ancients.Retrieve(freezerHeaderTable, h-1)
+		if err != nil {
+			return err
+		}
+		delete(roots, types.DecodeBlockBytesStream(header).StateRoot)
+	}
+	return nil
+}
+
+// deletes the trie nodes no stored state uses any more and clears the
+// marker a rewind left for it
+func (chain *BlockChain) sweepOrphans() error {
+	deleted, err := state.DeleteOrphans(chain.Database)
+	if err != nil {
+		return err
+	}
+	slog.Info("deleted orphaned state", "nodes", deleted)
+	return chain.Database.Delete(orphanSweepKey)
+}
+
+// finishes the orphan sweep of a rewind a crash interrupted
+func (chain *BlockChain) resumeOrphanSweep() error {
+	if chain.Options.ReadOnly {
+		return nil
+	}
+	if ok, _ := chain.Database.Has(orphanSweepKey); !ok {
+		return nil
+	}
+	return chain.sweepOrphans()
+}
+
+// drops everything held in memory about blocks above height
+func (chain *BlockChain) forgetAbove(height uint64) {
+	chain.headerCache.Purge()
+	chain.bodyCache.Purge()
+	chain.receiptsCache.Purge()
+	chain.stateCache.Purge()
+
+	for key := range chain.finality.votes {
+		if key.height > height {
+			delete(chain.finality.votes, key)
+		}
+	}
+	if chain.pruner != nil {
+		for root, h := range chain.pruner.recent {
+			if h > height {
+				delete(chain.pruner.recent, root)
+			}
+		}
+		chain.pruner.lastPruned = min(chain.pruner.lastPruned, height)
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/params"
+)
+
+// opens a proof of work chain without block rewards, so blocks that carry
+// the same transactions end at the same state root
+func newTestRewardlessChain(t *testing.T, dir string, alloc common.Address) *BlockChain {
+	t.Helper()
+	config := *params.DefaultChainConfig
+	config.Consensus = params.ProofOfWork
+	config.Alloc = map[common.Address]uint64{alloc: 1_000_000_000}
+	config.Issuance = nil
+	config.Pow = &params.PowConfig{BlockTime: 10, GenesisDifficulty: 16, MinimumDifficulty: 1, BoundDivisor: 1}
+	chain, err := NewChainWithConfig(&config, &Options{DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { chain.Close() })
+	chain.Engine.(*ProofOfWork).SetCoinbase(alloc)
+	return chain
+}
+
+// a rewind must keep the state of a side block that shares its root with a
+// removed canonical block
+func TestSetHeadSharedRoot(t *testing.T) {
+	w := setupTestWallet(t)
+	base := time.Now().Unix() - 100000
+	chain := newTestRewardlessChain(t, "db", w.Address)
+	other := newTestRewardlessChain(t, "other", w.Address)
+
+	first := insertTestBlockAt(t, chain, base)
+	if err := other.Insert(first); err != nil {
+		t.Fatal(err)
+	}
+	tx := signedTestTx(t, w, 0, common.Address{1}, 1)
+	second := insertTestBlockAt(t, chain, base+100, tx)
+	third := insertTestBlockAt(t, chain, base+200, signedTestTx(t, w, 1, common.Address{1}, 1))
+
+	// the same transfer in a side block at another time ends at the same state
+	side := insertTestBlockAt(t, other, base+150, tx)
+	if side.StateRoot != second.StateRoot {
+		t.Fatal("side block does not share the state root")
+	}
+	if err := chain.Insert(side); err != nil {
+		t.Fatal(err)
+	}
+	if chain.CurrentBlock().Hash != third.Hash {
+		t.Fatal("side block took over the head")
+	}
+
+	if err := chain.SetHead(1); err != nil {
+		t.Fatal(err)
+	}
+	if !state.HasRoot(chain.Database, second.StateRoot) {
+		t.Fatal("the state of the side block was deleted")
+	}
+	if state.HasRoot(chain.Database, third.StateRoot) {
+		t.Fatal("the state only the removed block used is still stored")
+	}
+	if ok, _ := chain.Database.Has(orphanSweepKey); ok {
+		t.Fatal("the orphan sweep marker was left behind")
+	}
+
+	// the side branch can still be extended
+	next := insertTestBlockAt(t, other, base+250)
+	if err := chain.Insert(next); err != nil {
+		t.Fatal(err)
+	}
+	if chain.CurrentBlock().Hash != next.Hash {
+		t.Fatal("the side branch did not become canonical")
+	}
+	if _, err := chain.VerifyChain(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a sweep a crash interrupted is finished when the chain is opened again
+func TestResumeOrphanSweep(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestRewardlessChain(t, "db", w.Address)
+	if err := chain.Database.Put(orphanSweepKey, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	chain.Close()
+
+	chain = newTestRewardlessChain(t, "db", w.Address)
+	if ok, _ := chain.Database.Has(orphanSweepKey); ok {
+		t.Fatal("the orphan sweep was not finished on start")
+	}
+}
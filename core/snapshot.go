@@ -0,0 +1,525 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/snapshot"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+)
+
+// number of recent blocks whose account changes the snapshot keeps in
+// memory as diff layers. older changes are flattened into the disk layer
+const snapshotLayers = 128
+
+// a snapshot file starts with snapshotMagic, then the height, block hash and
+// state root it was taken at, the supply, the rewarded height and the number
+// of accounts and of stakes. the block follows as an export record, its
+// length as 4 big endian bytes and then the block, and after it one record
+// per account in address order: the address, the balance and the nonce.
+// then come the stakes in address order: the address, the active and pending
+// stake, the randao commitment and a byte that is 1 for an exiting stake.
+// last is the state an engine like proof of authority keeps next to the
+// chain, as of the block: its length as 4 big endian bytes, 0 for none,
+// and the checkpoint. other integers are 8 big endian bytes and the whole
+// stream may be gzipped
+var snapshotMagic = []byte("NEXSNAP4")
+
+// largest account count ImportSnapshot accepts, guards against corrupt headers
+const maxSnapshotAccounts = 1 << 32
+
+// size of a stake record in a snapshot
+const snapshotStakeRecord = common.AddressLength + 16 + common.HashLength + 1
+
+// the state root at the head of the chain
+func (chain *BlockChain) headStateRoot() common.Hash {
+	if head := chain.head.Load(); head != nil {
+		return head.StateRoot
+	}
+	return chain.genesisRoot
+}
+
+// opens the flat account snapshot and rebuilds it from the head state if it
+// is missing or was left behind by an unclean shutdown. a snapshot that
+// cannot be rebuilt only costs speed, so that is logged and not returned
+func (chain *BlockChain) setupSnapshot() {
+	snaps, ok := snapshot.New(chain.Database)
+	chain.snaps = snaps
+	if (ok && snaps.Has(chain.headStateRoot())) || chain.Options.ReadOnly {
+		return
+	}
+	if err := chain.rebuildSnapshot(); err != nil {
+		slog.Error("failed to rebuild state snapshot", "err", err)
+	}
+}
+
+func (chain *BlockChain) rebuildSnapshot() error {
+	root := chain.headStateRoot()
+	statedb, err := chain.StateAt(root)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := chain.snaps.Rebuild(root, statedb); err != nil {
+		return err
+	}
+	slog.Info("rebuilt state snapshot", "root", root.Hex(), "elapsed", time.Since(start))
+	return nil
+}
+
+// adds the accounts a block changed as a diff layer on top of its parent's.
+// a block on a branch the snapshot no longer covers is skipped, reads at
+// its root go to the state instead
+func (chain *BlockChain) updateSnapshot(root, parentRoot common.Hash, statedb *state.StateDB, changed map[common.Address]*state.Account) {
+	if root == parentRoot {
+		return
+	}
+	if err := chain.snaps.Update(root, parentRoot, changed, statedb.Supply(), statedb.RewardedHeight()); err != nil {
+		slog.Debug("state snapshot skipped block", "root", root.Hex(), "err", err)
+	}
+}
+
+// flattens the diff layers below the head that are older than
+// snapshotLayers. after a reorg or rewind to a state the snapshot does not
+// cover it is rebuilt. failures are only logged, as reads fall back to the
+// state. the caller must hold chainmu
+func (chain *BlockChain) capSnapshot(keep int) {
+	if chain.Options.ReadOnly {
+		return
+	}
+	root := chain.headStateRoot()
+	if !chain.snaps.Has(root) {
+		if err := chain.rebuildSnapshot(); err != nil {
+			slog.Error("failed to rebuild state snapshot", "err", err)
+		}
+		return
+	}
+	if err := chain.snaps.Cap(root, keep); err != nil {
+		slog.Error("failed to flatten state snapshot", "err", err)
+	}
+}
+
+// returns the account addr has at the head, read from the snapshot when it
+// covers the head and from the state otherwise
+func (chain *BlockChain) headAccount(addr common.Address) (state.Account, error) {
+	if account, err := chain.snaps.Account(chain.headStateRoot(), addr); err == nil {
+		return account, nil
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		return state.Account{}, err
+	}
+	return state.Account{Balance: statedb.GetBalance(addr), Nonce: statedb.GetNonce(addr)}, nil
+}
+
+// writes the head block and every account at the head of the chain into w
+func (chain *BlockChain) ExportSnapshot(w io.Writer) error {
+	chain.chainmu.Lock()
+	var (
+		head = chain.head.Load()
+		root = chain.headStateRoot()
+	)
+	if head == nil {
+		chain.chainmu.Unlock()
+		return fmt.Errorf("%w: the chain holds no blocks", ErrExportRange)
+	}
+	block := chain.GetBlock(head.Hash)
+	accounts, supply, rewarded, err := chain.snaps.Accounts(root)
+	chain.chainmu.Unlock()
+	if block == nil {
+		return fmt.Errorf("%w: head block %d is missing", ErrExportRange, head.Height)
+	}
+	height, hash := head.Height, head.Hash
+	statedb, stateErr := chain.StateAt(root)
+	if stateErr != nil {
+		return stateErr
+	}
+	if err != nil {
+		accounts, supply, rewarded = make(map[common.Address]state.Account), statedb.Supply(), statedb.RewardedHeight()
+		err = statedb.ForEachAccount(func(addr common.Address, account state.Account) error {
+			accounts[addr] = account
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	// stakes are not in the flat snapshot, there are only as many as
+	// validators so they are read from the state
+	var (
+		stakeAddrs []common.Address
+		stakes     []state.Stake
+	)
+	err = statedb.ForEachStake(func(addr common.Address, s state.Stake) error {
+		stakeAddrs, stakes = append(stakeAddrs, addr), append(stakes, s)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	addrs := make([]common.Address, 0, len(accounts))
+	for addr := range accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+
+	header := append([]byte{}, snapshotMagic...)
+	header = binary.BigEndian.AppendUint64(header, height)
+	header = append(header, hash.Bytes()...)
+	header = append(header, root.Bytes()...)
+	header = binary.BigEndian.AppendUint64(header, supply)
+	header = binary.BigEndian.AppendUint64(header, rewarded)
+	header = binary.BigEndian.AppendUint64(header, uint64(len(addrs)))
+	header = binary.BigEndian.AppendUint64(header, uint64(len(stakeAddrs)))
+	encoded := block.BytesStream()
+	header = binary.BigEndian.AppendUint32(header, uint32(len(encoded)))
+	if _, err := w.Write(append(header, encoded...)); err != nil {
+		return err
+	}
+	record := make([]byte, common.AddressLength+16)
+	for _, addr := range addrs {
+		account := accounts[addr]
+		copy(record, addr.Bytes())
+		binary.BigEndian.PutUint64(record[common.AddressLength:], account.Balance)
+		binary.BigEndian.PutUint64(record[common.AddressLength+8:], account.Nonce)
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	record = make([]byte, snapshotStakeRecord)
+	for i, addr := range stakeAddrs {
+		copy(record, addr.Bytes())
+		binary.BigEndian.PutUint64(record[common.AddressLength:], stakes[i].Active)
+		binary.BigEndian.PutUint64(record[common.AddressLength+8:], stakes[i].Pending)
+		copy(record[common.AddressLength+16:], stakes[i].Commit.Bytes())
+		record[snapshotStakeRecord-1] = 0
+		if stakes[i].Exiting {
+			record[snapshotStakeRecord-1] = 1
+		}
+		if _, err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	var checkpoint []byte
+	if engine, ok := chain.Engine.(checkpointEngine); ok {
+		if checkpoint, err = engine.exportCheckpoint(chain, hash); err != nil {
+			return err
+		}
+	}
+	size := binary.BigEndian.AppendUint32(nil, uint32(len(checkpoint)))
+	if _, err := w.Write(append(size, checkpoint...)); err != nil {
+		return err
+	}
+	slog.Info("exported state snapshot", "height", height, "root", root.Hex(), "accounts", len(addrs), "stakes", len(stakeAddrs))
+	return nil
+}
+
+// reads a snapshot written by ExportSnapshot and stores it as a state. the
+// accounts and stakes are checked to be in order, to add up to the supply
+// and to hash
+// to the state root in the header. the snapshot is only trusted if its
+// block is already stored with that state root, or if it is the block
+// trusted names and hashes to it. an empty chain is then started at that
+// block: it becomes the first, finalized head, and blocks are inserted on
+// top of it as usual. proof of authority starts from the signer checkpoint
+// the snapshot carries, the randao of proof of stake still replays the
+// chain from genesis and cannot verify blocks on such a chain
+func (chain *BlockChain) ImportSnapshot(r io.Reader, trusted common.Hash) (*types.Block, error) {
+	if chain.Options.ReadOnly {
+		return nil, ErrReadOnlyDatabase
+	}
+	in, closer, err := openExportStream(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closer()
+
+	header := make([]byte, len(snapshotMagic)+8+2*common.HashLength+36)
+	if _, err := io.ReadFull(in, header); err != nil || !bytes.HasPrefix(header, snapshotMagic) {
+		return nil, ErrInvalidSnapshot
+	}
+	header = header[len(snapshotMagic):]
+	var (
+		height   = binary.BigEndian.Uint64(header)
+		hash     = common.Hash(header[8 : 8+common.HashLength])
+		root     = common.Hash(header[8+common.HashLength : 8+2*common.HashLength])
+		supply   = binary.BigEndian.Uint64(header[8+2*common.HashLength:])
+		rewarded = binary.BigEndian.Uint64(header[16+2*common.HashLength:])
+		count    = binary.BigEndian.Uint64(header[24+2*common.HashLength:])
+		staked   = binary.BigEndian.Uint64(header[32+2*common.HashLength:])
+		size     = binary.BigEndian.Uint32(header[40+2*common.HashLength:])
+	)
+	if count > maxSnapshotAccounts || staked > maxSnapshotAccounts {
+		return nil, fmt.Errorf("%w: %d accounts and %d stakes", ErrInvalidSnapshot, count, staked)
+	}
+	if size == 0 || size > maxExportRecord {
+		return nil, fmt.Errorf("%w: block of %d bytes", ErrInvalidSnapshot, size)
+	}
+	encoded := make([]byte, size)
+	if _, err := io.ReadFull(in, encoded); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	block := types.DecodeBlockBytesStream(encoded)
+	if block.Hash != hash || block.Height != height {
+		return nil, fmt.Errorf("%w: block is not the one in the header", ErrInvalidSnapshot)
+	}
+	if err := chain.checkSnapshotBlock(block, root, trusted); err != nil {
+		return nil, err
+	}
+
+	var (
+		accounts = make(map[common.Address]state.Account)
+		record   = make([]byte, common.AddressLength+16)
+		previous []byte
+		total    uint64
+	)
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(in, record); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		addr := common.Address(record[:common.AddressLength])
+		if previous != nil && bytes.Compare(previous, addr.Bytes()) >= 0 {
+			return nil, fmt.Errorf("%w: accounts out of order", ErrInvalidSnapshot)
+		}
+		previous = addr.Bytes()
+		account := state.Account{
+			Balance: binary.BigEndian.Uint64(record[common.AddressLength:]),
+			Nonce:   binary.BigEndian.Uint64(record[common.AddressLength+8:]),
+		}
+		if account.Balance == 0 && account.Nonce == 0 {
+			return nil, fmt.Errorf("%w: empty account %s", ErrInvalidSnapshot, addr.Hex())
+		}
+		if total+account.Balance < total {
+			return nil, fmt.Errorf("%w: balances overflow", ErrInvalidSnapshot)
+		}
+		total += account.Balance
+		accounts[addr] = account
+	}
+	stakes := make(map[common.Address]state.Stake)
+	record, previous = make([]byte, snapshotStakeRecord), nil
+	for i := uint64(0); i < staked; i++ {
+		if _, err := io.ReadFull(in, record); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		addr := common.Address(record[:common.AddressLength])
+		if previous != nil && bytes.Compare(previous, addr.Bytes()) >= 0 {
+			return nil, fmt.Errorf("%w: stakes out of order", ErrInvalidSnapshot)
+		}
+		previous = addr.Bytes()
+		if exiting := record[snapshotStakeRecord-1]; exiting > 1 {
+			return nil, fmt.Errorf("%w: stake of %s has exiting flag %d", ErrInvalidSnapshot, addr.Hex(), exiting)
+		}
+		s := state.Stake{
+			Active:  binary.BigEndian.Uint64(record[common.AddressLength:]),
+			Pending: binary.BigEndian.Uint64(record[common.AddressLength+8:]),
+			Commit:  common.Hash(record[common.AddressLength+16 : snapshotStakeRecord-1]),
+			Exiting: record[snapshotStakeRecord-1] == 1,
+		}
+		if s == (state.Stake{}) {
+			return nil, fmt.Errorf("%w: empty stake %s", ErrInvalidSnapshot, addr.Hex())
+		}
+		for _, amount := range []uint64{s.Active, s.Pending} {
+			if total+amount < total {
+				return nil, fmt.Errorf("%w: balances overflow", ErrInvalidSnapshot)
+			}
+			total += amount
+		}
+		stakes[addr] = s
+	}
+	checkpoint, err := readSnapshotCheckpoint(in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := in.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidSnapshot)
+	}
+	if total != supply {
+		return nil, fmt.Errorf("%w: balances and stakes add up to %d, supply is %d", ErrInvalidSnapshot, total, supply)
+	}
+
+	statedb := state.NewFromAccounts(chain.Database, accounts, stakes, supply, rewarded)
+	if got := statedb.IntermediateRoot(); got != root {
+		return nil, fmt.Errorf("%w: accounts hash to %s, header says %s", ErrSnapshotRoot, got.Hex(), root.Hex())
+	}
+	unlock := chain.lockState()
+	_, err = chain.commitState(statedb)
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("imported state snapshot", "height", height, "root", root.Hex(), "accounts", len(accounts))
+
+	chain.chainmu.Lock()
+	defer chain.chainmu.Unlock()
+	if chain.head.Load() == nil {
+		if err := chain.importCheckpoint(block, checkpoint); err != nil {
+			return nil, err
+		}
+		if err := chain.startAt(block); err != nil {
+			return nil, err
+		}
+	}
+	return block, nil
+}
+
+// reads the engine checkpoint that ends a snapshot
+func readSnapshotCheckpoint(in io.Reader) ([]byte, error) {
+	size := make([]byte, 4)
+	if _, err := io.ReadFull(in, size); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	n := binary.BigEndian.Uint32(size)
+	if n > maxExportRecord {
+		return nil, fmt.Errorf("%w: checkpoint of %d bytes", ErrInvalidSnapshot, n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	checkpoint := make([]byte, n)
+	if _, err := io.ReadFull(in, checkpoint); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return checkpoint, nil
+}
+
+// hands the engine the checkpoint of the block an empty chain starts at. it
+// is not covered by the state root, so it is as trusted as the snapshot's
+// source. an engine that keeps a checkpoint cannot start without one, and
+// one that keeps none must not be given one
+func (chain *BlockChain) importCheckpoint(b *types.Block, checkpoint []byte) error {
+	engine, ok := chain.Engine.(checkpointEngine)
+	switch {
+	case ok && checkpoint == nil:
+		return fmt.Errorf("%w: the engine needs a checkpoint and the snapshot has none", ErrInvalidSnapshot)
+	case !ok && checkpoint != nil:
+		return fmt.Errorf("%w: the snapshot has a checkpoint the engine does not keep", ErrInvalidSnapshot)
+	case !ok:
+		return nil
+	}
+	if err := engine.importCheckpoint(b, checkpoint); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return nil
+}
+
+// checks the block a snapshot claims to be taken at. a stored block has to
+// have the snapshot's state root, any other block has to be the trusted one
+// and hash to it, and the chain has to be empty so it can start there
+func (chain *BlockChain) checkSnapshotBlock(b *types.Block, root, trusted common.Hash) error {
+	if trusted != (common.Hash{}) && b.Hash != trusted {
+		return fmt.Errorf("%w: block %d is %s, not %s", ErrSnapshotUntrusted, b.Height, b.Hash.Hex(), trusted.Hex())
+	}
+	if local := chain.GetHeader(b.Hash); local != nil {
+		if got := local.StateRoot; got != root {
+			return fmt.Errorf("%w: block %d has state root %s, snapshot says %s", ErrSnapshotRoot, b.Height, got.Hex(), root.Hex())
+		}
+		return nil
+	}
+	if trusted == (common.Hash{}) {
+		return fmt.Errorf("%w: block %d %s is not stored", ErrSnapshotUntrusted, b.Height, b.Hash.Hex())
+	}
+	if b.ComputeHash() != b.Hash || b.TxHash != types.DeriveTxHash(b.Transactions) {
+		return fmt.Errorf("%w: block does not hash to %s", ErrInvalidSnapshot, b.Hash.Hex())
+	}
+	if b.StateRoot != root {
+		return fmt.Errorf("%w: block %d has state root %s, snapshot says %s", ErrSnapshotRoot, b.Height, b.StateRoot.Hex(), root.Hex())
+	}
+	if chain.head.Load() != nil {
+		return fmt.Errorf("%w: block %d is not stored and the chain is not empty", ErrSnapshotUntrusted, b.Height)
+	}
+	return nil
+}
+
+// makes b, whose state is stored, the first block of an empty chain. nothing
+// below it is stored, so it is finalized from the start. the block and the
+// head go out in one batch, an interrupted start is redone by importing the
+// snapshot again. the caller must hold chainmu
+func (chain *BlockChain) startAt(b *types.Block) error {
+	batch := chain.Database.NewBatch()
+	if err := batch.Put(blockKey(b.Hash), b.BytesStream()); err != nil {
+		return err
+	}
+	if _, err := chain.writeTd(batch, b); err != nil {
+		return err
+	}
+	if err := chain.writeCanonical(batch, b); err != nil {
+		return err
+	}
+	for _, key := range [][]byte{tailBlockKey, finalizedBlockKey, headBlockKey} {
+		if err := batch.Put(key, b.Hash.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	chain.cacheBlock(b)
+	chain.finalized.Store(b)
+	chain.head.Store(b)
+	chain.capSnapshot(snapshotLayers)
+	slog.Info("started chain from state snapshot", "height", b.Height, "hash", b.Hash.Hex())
+	return nil
+}
+
+// returns the height of the first block the chain holds. that is 1 unless
+// the chain was started from a snapshot
+func (chain *BlockChain) tailHeight() uint64 {
+	if hash, err := chain.Database.Get(tailBlockKey); err == nil {
+		if tail := chain.GetHeader(common.Hash(hash)); tail != nil {
+			return tail.Height
+		}
+	}
+	return 1
+}
+
+// exports the snapshot into the file at path, gzipped if the path ends in .gz
+func (chain *BlockChain) ExportSnapshotFile(path string) error {
+	return writeExportFile(path, chain.ExportSnapshot)
+}
+
+// imports the snapshot in the file at path and returns its block
+func (chain *BlockChain) ImportSnapshotFile(path string, trusted common.Hash) (*types.Block, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return chain.ImportSnapshot(file, trusted)
+}
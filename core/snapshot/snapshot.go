@@ -0,0 +1,425 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+// Package snapshot keeps a flat copy of the account state next to the
+// content addressed state, so single accounts can be read without loading
+// a whole state.
+package snapshot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+var (
+	ErrUnknownRoot   = errors.New("no snapshot layer for state root")
+	ErrUnknownParent = errors.New("no snapshot layer for the parent state root")
+)
+
+// the disk layer stores every account flat under AccountPrefix + address,
+// and its root, supply and rewarded height under rootKey. both are always
+// written in the same batch
+var (
+	AccountPrefix = "s"
+	rootKey       = []byte("SnapshotRoot")
+)
+
+// a view of the account state at one state root
+type layer interface {
+	Root() common.Hash
+	account(addr common.Address) (*state.Account, error)
+	totals() (supply uint64, rewardedHeight uint64)
+}
+
+// the state at the bottom of the tree, stored flat in the database
+type diskLayer struct {
+	db             nexadb.KeyValueStore // the account table
+	root           common.Hash
+	supply         uint64
+	rewardedHeight uint64
+}
+
+func (dl *diskLayer) Root() common.Hash { return dl.root }
+
+func (dl *diskLayer) totals() (uint64, uint64) { return dl.supply, dl.rewardedHeight }
+
+func (dl *diskLayer) account(addr common.Address) (*state.Account, error) {
+	if ok, err := dl.db.Has(addr.Bytes()); err != nil || !ok {
+		return nil, err
+	}
+	data, err := dl.db.Get(addr.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return decodeAccount(data), nil
+}
+
+// the accounts one block changed on top of its parent layer, kept in memory
+type diffLayer struct {
+	parent         layer
+	root           common.Hash
+	accounts       map[common.Address]*state.Account // nil for an account that was emptied
+	supply         uint64
+	rewardedHeight uint64
+}
+
+func (dl *diffLayer) Root() common.Hash { return dl.root }
+
+func (dl *diffLayer) totals() (uint64, uint64) { return dl.supply, dl.rewardedHeight }
+
+func (dl *diffLayer) account(addr common.Address) (*state.Account, error) {
+	if account, ok := dl.accounts[addr]; ok {
+		return account, nil
+	}
+	return dl.parent.account(addr)
+}
+
+// Tree holds the disk layer and the diff layers of recent blocks on top of
+// it, which may branch. it is safe for concurrent use.
+type Tree struct {
+	lock   sync.RWMutex
+	db     nexadb.KeyValueStore
+	disk   *diskLayer
+	layers map[common.Hash]layer
+}
+
+// opens the snapshot stored in db. it reports false if there is none yet,
+// in which case the tree has to be filled with Rebuild before it is used
+func New(db nexadb.KeyValueStore) (*Tree, bool) {
+	t := &Tree{db: db, layers: make(map[common.Hash]layer)}
+	data, err := db.Get(rootKey)
+	if err != nil || len(data) != common.HashLength+16 {
+		return t, false
+	}
+	t.disk = &diskLayer{
+		db:             nexadb.Table(db, AccountPrefix),
+		root:           common.Hash(data[:common.HashLength]),
+		supply:         binary.BigEndian.Uint64(data[common.HashLength:]),
+		rewardedHeight: binary.BigEndian.Uint64(data[common.HashLength+8:]),
+	}
+	t.layers[t.disk.root] = t.disk
+	return t, true
+}
+
+// returns the root of the disk layer, the zero hash if there is none
+func (t *Tree) DiskRoot() common.Hash {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.disk == nil {
+		return common.Hash{}
+	}
+	return t.disk.root
+}
+
+// reports whether the tree can serve reads at root
+func (t *Tree) Has(root common.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, ok := t.layers[root]
+	return ok
+}
+
+// returns the account addr has at root, the zero account if it has none
+func (t *Tree) Account(root common.Hash, addr common.Address) (state.Account, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	l, ok := t.layers[root]
+	if !ok {
+		return state.Account{}, ErrUnknownRoot
+	}
+	account, err := l.account(addr)
+	if err != nil || account == nil {
+		return state.Account{}, err
+	}
+	return *account, nil
+}
+
+// returns the supply and rewarded height at root
+func (t *Tree) Totals(root common.Hash) (uint64, uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	l, ok := t.layers[root]
+	if !ok {
+		return 0, 0, ErrUnknownRoot
+	}
+	supply, rewarded := l.totals()
+	return supply, rewarded, nil
+}
+
+// adds a diff layer for root on top of the layer for parent
+func (t *Tree) Update(root, parent common.Hash, accounts map[common.Address]*state.Account, supply, rewardedHeight uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok {
+		return nil
+	}
+	p, ok := t.layers[parent]
+	if !ok {
+		return ErrUnknownParent
+	}
+	t.layers[root] = &diffLayer{
+		parent:         p,
+		root:           root,
+		accounts:       accounts,
+		supply:         supply,
+		rewardedHeight: rewardedHeight,
+	}
+	return nil
+}
+
+// flattens the layers more than keep diffs below root into the disk layer.
+// layers on branches that do not lead to root are dropped once the disk
+// layer moves past their fork point
+func (t *Tree) Cap(root common.Hash, keep int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	l, ok := t.layers[root]
+	if !ok {
+		return ErrUnknownRoot
+	}
+	// collect the diffs from root down to the disk layer, highest first
+	var diffs []*diffLayer
+	for {
+		diff, ok := l.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		l = diff.parent
+	}
+	if len(diffs) <= keep {
+		return nil
+	}
+
+	// write the oldest diffs into the disk in one batch
+	flatten := diffs[keep:]
+	batch := t.db.NewBatch()
+	for i := len(flatten) - 1; i >= 0; i-- {
+		for addr, account := range flatten[i].accounts {
+			var err error
+			if account == nil {
+				err = batch.Delete(accountKey(addr))
+			} else {
+				err = batch.Put(accountKey(addr), encodeAccount(account))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	bottom := flatten[0]
+	disk := &diskLayer{
+		db:             t.disk.db,
+		root:           bottom.root,
+		supply:         bottom.supply,
+		rewardedHeight: bottom.rewardedHeight,
+	}
+	if err := batch.Put(rootKey, encodeRoot(disk)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	// keep the layers built on top of the flattened ones, which now sit on
+	// the new disk layer. branches forking further down are dropped
+	kept := map[common.Hash]layer{disk.root: disk}
+	for r, l := range t.layers {
+		if diff, ok := l.(*diffLayer); ok && diff != bottom && builtOn(diff, bottom) {
+			kept[r] = diff
+		}
+	}
+	for _, l := range kept {
+		if diff, ok := l.(*diffLayer); ok && diff.parent == layer(bottom) {
+			diff.parent = disk
+		}
+	}
+	t.disk, t.layers = disk, kept
+	return nil
+}
+
+// reports whether diff sits somewhere on top of base
+func builtOn(diff *diffLayer, base *diffLayer) bool {
+	for {
+		if diff.parent == layer(base) {
+			return true
+		}
+		parent, ok := diff.parent.(*diffLayer)
+		if !ok {
+			return false
+		}
+		diff = parent
+	}
+}
+
+// replaces the whole snapshot with the given state, which has to be committed
+// under root
+func (t *Tree) Rebuild(root common.Hash, statedb *state.StateDB) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	accounts := nexadb.Table(t.db, AccountPrefix)
+	batch := t.db.NewBatch()
+	iter := accounts.NewIterator(nil)
+	for iter.Next() {
+		if err := batch.Delete(append([]byte(AccountPrefix), iter.Key()...)); err != nil {
+			iter.Release()
+			return err
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	err := statedb.ForEachAccount(func(addr common.Address, account state.Account) error {
+		return batch.Put(accountKey(addr), encodeAccount(&account))
+	})
+	if err != nil {
+		return err
+	}
+	disk := &diskLayer{
+		db:             accounts,
+		root:           root,
+		supply:         statedb.Supply(),
+		rewardedHeight: statedb.RewardedHeight(),
+	}
+	if err := batch.Put(rootKey, encodeRoot(disk)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	t.disk = disk
+	t.layers = map[common.Hash]layer{root: disk}
+	return nil
+}
+
+// returns every non-empty account at root together with the supply and
+// rewarded height there
+func (t *Tree) Accounts(root common.Hash) (map[common.Address]state.Account, uint64, uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	l, ok := t.layers[root]
+	if !ok {
+		return nil, 0, 0, ErrUnknownRoot
+	}
+	var diffs []*diffLayer
+	for {
+		diff, ok := l.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		l = diff.parent
+	}
+
+	accounts := make(map[common.Address]state.Account)
+	iter := t.disk.db.NewIterator(nil)
+	for iter.Next() {
+		if len(iter.Key()) == common.AddressLength {
+			accounts[common.Address(iter.Key())] = *decodeAccount(iter.Value())
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, 0, 0, err
+	}
+	for i := len(diffs) - 1; i >= 0; i-- {
+		for addr, account := range diffs[i].accounts {
+			if account == nil {
+				delete(accounts, addr)
+			} else {
+				accounts[addr] = *account
+			}
+		}
+	}
+	supply, rewarded := t.layers[root].totals()
+	return accounts, supply, rewarded, nil
+}
+
+func accountKey(addr common.Address) []byte {
+	return append([]byte(AccountPrefix), addr.Bytes()...)
+}
+
+func encodeAccount(account *state.Account) []byte {
+	enc := make([]byte, 16)
+	binary.BigEndian.PutUint64(enc, account.Balance)
+	binary.BigEndian.PutUint64(enc[8:], account.Nonce)
+	return enc
+}
+
+func decodeAccount(data []byte) *state.Account {
+	if len(data) != 16 {
+		return &state.Account{}
+	}
+	return &state.Account{
+		Balance: binary.BigEndian.Uint64(data),
+		Nonce:   binary.BigEndian.Uint64(data[8:]),
+	}
+}
+
+func encodeRoot(dl *diskLayer) []byte {
+	enc := append([]byte{}, dl.root.Bytes()...)
+	enc = binary.BigEndian.AppendUint64(enc, dl.supply)
+	return binary.BigEndian.AppendUint64(enc, dl.rewardedHeight)
+}
+
+// decodes a stored snapshot account, for tools that inspect the database
+func DecodeAccount(data []byte) (any, error) {
+	if len(data) != 16 {
+		return nil, fmt.Errorf("value is %d bytes, not a snapshot account", len(data))
+	}
+	return decodeAccount(data), nil
+}
+
+// decodes the stored root of the disk layer, for tools that inspect the database
+func DecodeRoot(data []byte) (any, error) {
+	if len(data) != common.HashLength+16 {
+		return nil, fmt.Errorf("value is %d bytes, not a snapshot root", len(data))
+	}
+	return struct {
+		Root           string
+		Supply         uint64
+		RewardedHeight uint64
+	}{
+		Root:           common.Hash(data[:common.HashLength]).Hex(),
+		Supply:         binary.BigEndian.Uint64(data[common.HashLength:]),
+		RewardedHeight: binary.BigEndian.Uint64(data[common.HashLength+8:]),
+	}, nil
+}
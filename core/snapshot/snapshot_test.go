@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+)
+
+// returns the balance addr has at root in tree
+func balance(t *testing.T, tree *Tree, root common.Hash, addr common.Address) uint64 {
+	t.Helper()
+	account, err := tree.Account(root, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account.Balance
+}
+
+// diff layers read through to their parents, capping flattens the old ones
+// into the disk layer and drops the branches that fork below it
+func TestTreeLayers(t *testing.T) {
+	db := memorydb.New()
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	statedb := state.NewFromAccounts(db, map[common.Address]state.Account{a: {Balance: 10}}, nil, 10, 0)
+	base := statedb.IntermediateRoot()
+
+	tree, ok := New(db)
+	if ok {
+		t.Fatal("an empty database has a snapshot")
+	}
+	if err := tree.Rebuild(base, statedb); err != nil {
+		t.Fatal(err)
+	}
+	first, second, side := common.Hash{1}, common.Hash{2}, common.Hash{3}
+	updates := []struct {
+		root, parent common.Hash
+		accounts     map[common.Address]*state.Account
+	}{
+		{first, base, map[common.Address]*state.Account{a: {Balance: 5, Nonce: 1}, b: {Balance: 5}}},
+		{second, first, map[common.Address]*state.Account{b: nil}},
+		{side, base, map[common.Address]*state.Account{c: {Balance: 10}}},
+	}
+	for _, u := range updates {
+		if err := tree.Update(u.root, u.parent, u.accounts, 10, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Update(common.Hash{4}, common.Hash{5}, nil, 0, 0); !errors.Is(err, ErrUnknownParent) {
+		t.Fatalf("update on an unknown parent: %v, want %v", err, ErrUnknownParent)
+	}
+	if balance(t, tree, second, a) != 5 || balance(t, tree, second, b) != 0 || balance(t, tree, first, b) != 5 {
+		t.Fatal("diff layers do not read through to their parents")
+	}
+
+	if err := tree.Cap(second, 1); err != nil {
+		t.Fatal(err)
+	}
+	if tree.DiskRoot() != first {
+		t.Fatalf("disk layer is at %x after the cap, want %x", tree.DiskRoot(), first)
+	}
+	if tree.Has(side) || tree.Has(base) {
+		t.Fatal("layers below the new disk layer were kept")
+	}
+	accounts, supply, _, err := tree.Accounts(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[a].Balance != 5 || supply != 10 {
+		t.Fatalf("accounts at the head are %v with supply %d", accounts, supply)
+	}
+
+	// the flattened state is what a reopened tree starts from
+	reopened, ok := New(db)
+	if !ok || reopened.DiskRoot() != first {
+		t.Fatal("the capped disk layer was not stored")
+	}
+	if balance(t, reopened, first, b) != 5 || reopened.Has(second) {
+		t.Fatal("the reopened tree does not hold the disk layer alone")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+)
+
+// the validator set is part of the state. a stake transaction moves coins
+// from the sender's balance into the pending part of its stake and an
+// unstake marks the whole stake as exiting. neither changes the set of the
+// running epoch: the first block of the next epoch folds pending stakes in
+// and pays exiting ones back before its transactions run, and every node
+// reads the set of an epoch from the state its blocks build on
+
+// locks amount of addr's balance as pending stake. the randao commitment is
+// registered with the first stake and kept by later ones
+func stake(statedb *state.StateDB, addr common.Address, amount uint64, commit common.Hash) error {
+	if amount == 0 {
+		return ErrStakeZero
+	}
+	current := statedb.GetStake(addr)
+	if current.Exiting {
+		return ErrStakeExiting
+	}
+	if current.Commit == (common.Hash{}) {
+		if commit == (common.Hash{}) {
+			return ErrStakeCommit
+		}
+		current.Commit = commit
+	}
+	if err := statedb.SubBalance(addr, amount); err != nil {
+		return err
+	}
+	current.Pending += amount
+	statedb.SetStake(addr, current)
+	return nil
+}
+
+// marks addr's stake to be paid back at the next epoch boundary. it keeps
+// validating until then
+func unstake(statedb *state.StateDB, addr common.Address) error {
+	current := statedb.GetStake(addr)
+	if current.Active == 0 && current.Pending == 0 {
+		return ErrNoStake
+	}
+	if current.Exiting {
+		return ErrStakeExiting
+	}
+	current.Exiting = true
+	statedb.SetStake(addr, current)
+	return nil
+}
+
+// folds the stake changes of the epoch that ended into the state, at the
+// first block of the next one. exiting stakes go back to their balance and
+// pending stakes become active
+func applyEpochStakes(statedb *state.StateDB) error {
+	stakes := make(map[common.Address]state.Stake)
+	err := statedb.ForEachStake(func(addr common.Address, s state.Stake) error {
+		if s.Exiting || s.Pending > 0 {
+			stakes[addr] = s
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for addr, s := range stakes {
+		if s.Exiting {
+			statedb.AddBalance(addr, s.Active+s.Pending)
+			statedb.SetStake(addr, state.Stake{})
+			continue
+		}
+		s.Active, s.Pending = s.Active+s.Pending, 0
+		statedb.SetStake(addr, s)
+	}
+	return nil
+}
+
+// reads the validator set of a block's epoch from the state the block builds
+// on, in address order. the first block of an epoch has not folded in the
+// changes of the epoch before yet, so they are applied to what is read
+func stakedValidators(statedb *state.StateDB, startsEpoch bool) ([]*ActiveValidator, error) {
+	var validators []*ActiveValidator
+	err := statedb.ForEachStake(func(addr common.Address, s state.Stake) error {
+		weight := s.Active
+		if startsEpoch {
+			if s.Exiting {
+				return nil
+			}
+			weight += s.Pending
+		}
+		if weight > 0 {
+			validators = append(validators, &ActiveValidator{Address: addr, Stake: weight, RandaoCommit: s.Commit})
+		}
+		return nil
+	})
+	return validators, err
+}
@@ -0,0 +1,186 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"sync"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+// number of keys Prune deletes under one hold of the guard
+const pruneBatch = 1024
+
+// PruneGuard lets states be committed while Prune runs. a commit holds the
+// lock and records its root, Prune holds it for every batch it deletes and
+// keeps the states recorded since it started
+type PruneGuard struct {
+	sync.Mutex
+	committed []common.Hash
+}
+
+// records a committed state, the caller must hold the lock
+func (g *PruneGuard) Committed(root common.Hash) {
+	g.committed = append(g.committed, root)
+}
+
+// hands out the states recorded since the last call, the caller must hold
+// the lock
+func (g *PruneGuard) take() []common.Hash {
+	roots := g.committed
+	g.committed = nil
+	return roots
+}
+
+// the roots and nodes a prune keeps
+type pruneMarks struct {
+	db    nexadb.KeyValueStore
+	roots map[common.Hash]struct{}
+	nodes map[common.Hash]struct{}
+}
+
+// keeps root and every node of its account and stake tries
+func (m *pruneMarks) mark(root common.Hash) error {
+	m.roots[root] = struct{}{}
+	if root == EmptyRoot {
+		return nil
+	}
+	data, err := nexadb.Table(m.db, RootPrefix).Get(root.Bytes())
+	if err != nil {
+		// deleted in the meantime, nothing can build on it any more
+		return nil
+	}
+	obj, err := decodeRoot(data)
+	if err != nil {
+		return err
+	}
+	nodes := nexadb.Table(m.db, NodePrefix)
+	if err := markTrie(nodes, obj.Accounts, m.nodes); err != nil {
+		return err
+	}
+	return markTrie(nodes, obj.Stakes, m.nodes)
+}
+
+// deletes every stored state whose root is not in keep, then every trie
+// node that none of the kept states use. it returns how many roots and nodes
+// were deleted. states may be committed while it runs as long as they go
+// through guard
+func Prune(db nexadb.KeyValueStore, keep map[common.Hash]struct{}, guard *PruneGuard) (int, int, error) {
+	marks := &pruneMarks{
+		db:    db,
+		roots: make(map[common.Hash]struct{}, len(keep)),
+		nodes: make(map[common.Hash]struct{}),
+	}
+	for root := range keep {
+		if err := marks.mark(root); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// sweep the roots and then the nodes that are not marked
+	deleted, err := sweep(nexadb.Table(db, RootPrefix), marks, marks.roots, guard)
+	if err != nil {
+		return deleted, 0, err
+	}
+	nodes, err := sweep(nexadb.Table(db, NodePrefix), marks, marks.nodes, guard)
+	return deleted, nodes, err
+}
+
+// deletes every key of table whose hash is not in live, a batch at a time.
+// before each batch the states committed in the meantime are marked
+func sweep(table nexadb.KeyValueStore, marks *pruneMarks, live map[common.Hash]struct{}, guard *PruneGuard) (int, error) {
+	iter := table.NewIterator(nil)
+	defer iter.Release()
+
+	deleted := 0
+	var batch []common.Hash
+	flush := func() error {
+		guard.Lock()
+		defer guard.Unlock()
+		for _, root := range guard.take() {
+			if err := marks.mark(root); err != nil {
+				return err
+			}
+		}
+		for _, hash := range batch {
+			if _, ok := live[hash]; ok {
+				continue
+			}
+			if err := table.Delete(hash.Bytes()); err != nil {
+				return err
+			}
+			deleted++
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if _, ok := live[common.Hash(key)]; ok {
+			continue
+		}
+		if batch = append(batch, common.Hash(key)); len(batch) == pruneBatch {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// deletes every trie node that no stored state uses, which DeleteRoot
+// leaves behind. it returns how many nodes were deleted. nothing may commit
+// state while it runs
+func DeleteOrphans(db nexadb.KeyValueStore) (int, error) {
+	iter := nexadb.Table(db, RootPrefix).NewIterator(nil)
+	defer iter.Release()
+
+	marks := &pruneMarks{
+		db:    db,
+		roots: make(map[common.Hash]struct{}),
+		nodes: make(map[common.Hash]struct{}),
+	}
+	for iter.Next() {
+		if len(iter.Key()) != common.HashLength {
+			continue
+		}
+		if err := marks.mark(common.Hash(iter.Key())); err != nil {
+			return 0, err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+	return sweep(nexadb.Table(db, NodePrefix), marks, marks.nodes, new(PruneGuard))
+}
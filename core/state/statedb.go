@@ -0,0 +1,615 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrMissingRoot         = errors.New("state root not found in database")
+	ErrMissingNode         = errors.New("trie node not found in database")
+	ErrInvalidEncoding     = errors.New("state object has an invalid encoding")
+)
+
+// state is stored content addressed. the accounts live in a trie whose
+// nodes are keyed by the hash of their encoding, and a root object holds
+// the root of that trie together with the totals of the state. two states
+// share every trie node they have in common. roots and nodes each live in
+// their own table of the chain database.
+const (
+	RootPrefix = "o" // root -> root object
+	NodePrefix = "u" // node hash -> trie node
+)
+
+// roots, accounts and stakes are hashed over a fixed width big endian
+// encoding, so the same state has the same root on every node and in every
+// process:
+//
+//	account:     balance, nonce
+//	stake:       active, pending, randao commitment, exiting flag
+//	root object: supply, rewarded height, account trie root, stake trie root
+//
+// a state without stakes leaves the stake trie root out of its root object,
+// so it keeps the root it had before stakes were part of the state
+const (
+	accountLength   = 16
+	stakeLength     = 16 + common.HashLength + 1
+	rootLength      = 16 + common.HashLength
+	stakeRootLength = rootLength + common.HashLength
+)
+
+// root of a state with no accounts and no supply
+var EmptyRoot = common.SHA256(nil)
+
+type Account struct {
+	Balance uint64
+	Nonce   uint64
+}
+
+func (a *Account) empty() bool {
+	return a.Balance == 0 && a.Nonce == 0
+}
+
+// Stake is what an account has locked up to validate with. the coins
+// count towards the supply but are no longer part of the balance
+type Stake struct {
+	Active  uint64      // weight in the validator set of the current epoch
+	Pending uint64      // staked during the epoch, active from the next one
+	Commit  common.Hash // randao commitment registered with the first stake
+	Exiting bool        // the whole stake is paid back at the next epoch
+}
+
+func (s *Stake) empty() bool {
+	return *s == Stake{}
+}
+
+// what is stored under a root key
+type rootObject struct {
+	Supply         uint64
+	RewardedHeight uint64
+	Accounts       common.Hash // root of the account trie
+	Stakes         common.Hash // root of the stake trie
+}
+
+func encodeAccount(account *Account) []byte {
+	enc := make([]byte, accountLength)
+	binary.BigEndian.PutUint64(enc, account.Balance)
+	binary.BigEndian.PutUint64(enc[8:], account.Nonce)
+	return enc
+}
+
+func decodeAccount(data []byte) (*Account, error) {
+	if len(data) != accountLength {
+		return nil, ErrInvalidEncoding
+	}
+	return &Account{
+		Balance: binary.BigEndian.Uint64(data),
+		Nonce:   binary.BigEndian.Uint64(data[8:]),
+	}, nil
+}
+
+func encodeStake(stake *Stake) []byte {
+	enc := make([]byte, stakeLength)
+	binary.BigEndian.PutUint64(enc, stake.Active)
+	binary.BigEndian.PutUint64(enc[8:], stake.Pending)
+	copy(enc[16:], stake.Commit.Bytes())
+	if stake.Exiting {
+		enc[stakeLength-1] = 1
+	}
+	return enc
+}
+
+func decodeStake(data []byte) (*Stake, error) {
+	if len(data) != stakeLength || data[stakeLength-1] > 1 {
+		return nil, ErrInvalidEncoding
+	}
+	return &Stake{
+		Active:  binary.BigEndian.Uint64(data),
+		Pending: binary.BigEndian.Uint64(data[8:]),
+		Commit:  common.Hash(data[16 : 16+common.HashLength]),
+		Exiting: data[stakeLength-1] == 1,
+	}, nil
+}
+
+func encodeRoot(obj *rootObject) []byte {
+	size := rootLength
+	if obj.Stakes != emptyTrie {
+		size = stakeRootLength
+	}
+	enc := make([]byte, size)
+	binary.BigEndian.PutUint64(enc, obj.Supply)
+	binary.BigEndian.PutUint64(enc[8:], obj.RewardedHeight)
+	copy(enc[16:], obj.Accounts.Bytes())
+	if obj.Stakes != emptyTrie {
+		copy(enc[rootLength:], obj.Stakes.Bytes())
+	}
+	return enc
+}
+
+func decodeRoot(data []byte) (*rootObject, error) {
+	if len(data) != rootLength && len(data) != stakeRootLength {
+		return nil, ErrInvalidEncoding
+	}
+	obj := &rootObject{
+		Supply:         binary.BigEndian.Uint64(data),
+		RewardedHeight: binary.BigEndian.Uint64(data[8:]),
+		Accounts:       common.Hash(data[16:rootLength]),
+	}
+	if len(data) == stakeRootLength {
+		if obj.Stakes = common.Hash(data[rootLength:]); obj.Stakes == emptyTrie {
+			return nil, ErrInvalidEncoding
+		}
+	}
+	return obj, nil
+}
+
+// StateDB holds every account at some point of the chain. accounts are read
+// from the trie the first time they are used, and changes are kept in memory
+// until Commit writes the trie nodes on their paths under a new root.
+type StateDB struct {
+	db       nexadb.KeyValueStore
+	roots    nexadb.KeyValueStore
+	trie     *trie
+	accounts map[common.Address]*Account // accounts read or changed so far
+
+	// accounts changed since the state was opened or last committed, and
+	// those of them not written into the trie yet
+	dirty   map[common.Address]struct{}
+	pending map[common.Address]struct{}
+
+	// stakes live in a trie of their own and are loaded and written the
+	// same way as accounts
+	stakeTrie     *trie
+	stakes        map[common.Address]*Stake
+	pendingStakes map[common.Address]struct{}
+
+	// total NEX in existence
+	supply uint64
+	// height of the last block whose finality voters were paid
+	rewardedHeight uint64
+
+	// the first failure to read the trie. reads cannot return it, so it is
+	// kept and handed out by Error and Commit
+	err error
+}
+
+// opens the state with the given root. only the root object is read, the
+// accounts are loaded as they are used
+func New(root common.Hash, db nexadb.KeyValueStore) (*StateDB, error) {
+	nodes := nexadb.Table(db, NodePrefix)
+	s := &StateDB{
+		db:       db,
+		roots:    nexadb.Table(db, RootPrefix),
+		trie:     newTrie(emptyTrie, nodes),
+		accounts: make(map[common.Address]*Account),
+		dirty:    make(map[common.Address]struct{}),
+		pending:  make(map[common.Address]struct{}),
+
+		stakeTrie:     newTrie(emptyTrie, nodes),
+		stakes:        make(map[common.Address]*Stake),
+		pendingStakes: make(map[common.Address]struct{}),
+	}
+	if root == EmptyRoot {
+		return s, nil
+	}
+
+	data, err := s.roots.Get(root.Bytes())
+	if err != nil {
+		return nil, ErrMissingRoot
+	}
+	obj, err := decodeRoot(data)
+	if err != nil {
+		return nil, err
+	}
+	s.supply = obj.Supply
+	s.rewardedHeight = obj.RewardedHeight
+	s.trie = newTrie(obj.Accounts, nodes)
+	s.stakeTrie = newTrie(obj.Stakes, nodes)
+	return s, nil
+}
+
+// reports whether the root object of a state is stored
+func HasRoot(db nexadb.KeyValueReader, root common.Hash) bool {
+	if root == EmptyRoot {
+		return true
+	}
+	ok, _ := db.Has(append([]byte(RootPrefix), root.Bytes()...))
+	return ok
+}
+
+// returns an independent copy of the state. the copy shares the trie with
+// s, so it costs as much as the accounts s has touched
+func (s *StateDB) Copy() *StateDB {
+	cpy := &StateDB{
+		db:             s.db,
+		roots:          s.roots,
+		trie:           s.trie.copy(),
+		accounts:       make(map[common.Address]*Account, len(s.accounts)),
+		dirty:          make(map[common.Address]struct{}, len(s.dirty)),
+		pending:        make(map[common.Address]struct{}, len(s.pending)),
+		stakeTrie:      s.stakeTrie.copy(),
+		stakes:         make(map[common.Address]*Stake, len(s.stakes)),
+		pendingStakes:  make(map[common.Address]struct{}, len(s.pendingStakes)),
+		supply:         s.supply,
+		rewardedHeight: s.rewardedHeight,
+		err:            s.err,
+	}
+	for addr, account := range s.accounts {
+		acc := *account
+		cpy.accounts[addr] = &acc
+	}
+	for addr := range s.dirty {
+		cpy.dirty[addr] = struct{}{}
+	}
+	for addr := range s.pending {
+		cpy.pending[addr] = struct{}{}
+	}
+	for addr, stake := range s.stakes {
+		st := *stake
+		cpy.stakes[addr] = &st
+	}
+	for addr := range s.pendingStakes {
+		cpy.pendingStakes[addr] = struct{}{}
+	}
+	return cpy
+}
+
+// builds a state from flat lists of accounts and stakes, such as a
+// snapshot. nothing is written until Commit
+func NewFromAccounts(db nexadb.KeyValueStore, accounts map[common.Address]Account, stakes map[common.Address]Stake, supply, rewardedHeight uint64) *StateDB {
+	s, _ := New(EmptyRoot, db)
+	for addr, account := range accounts {
+		acc := account
+		s.accounts[addr] = &acc
+		s.dirty[addr] = struct{}{}
+		s.pending[addr] = struct{}{}
+	}
+	for addr, stake := range stakes {
+		st := stake
+		s.stakes[addr] = &st
+		s.pendingStakes[addr] = struct{}{}
+	}
+	s.supply = supply
+	s.rewardedHeight = rewardedHeight
+	return s
+}
+
+// returns the first error reading the trie ran into, the state cannot be
+// trusted once there is one
+func (s *StateDB) Error() error {
+	return s.err
+}
+
+// returns the accounts changed since the state was opened or last
+// committed. an account that ended up empty maps to nil, since empty
+// accounts are not part of the state
+func (s *StateDB) DirtyAccounts() map[common.Address]*Account {
+	changed := make(map[common.Address]*Account, len(s.dirty))
+	for addr := range s.dirty {
+		account := s.accounts[addr]
+		if account == nil || account.empty() {
+			changed[addr] = nil
+			continue
+		}
+		acc := *account
+		changed[addr] = &acc
+	}
+	return changed
+}
+
+// calls fn for every non-empty account in address order and stops at the
+// first error. it walks the whole trie, so it is meant for snapshots and not
+// for block processing
+func (s *StateDB) ForEachAccount(fn func(addr common.Address, account Account) error) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return s.trie.forEach(func(key, value []byte) error {
+		account, err := decodeAccount(value)
+		if err != nil {
+			return err
+		}
+		return fn(common.Address(key), *account)
+	})
+}
+
+// calls fn for every stake in address order and stops at the first error.
+// it walks the whole stake trie, which only holds the validators
+func (s *StateDB) ForEachStake(fn func(addr common.Address, stake Stake) error) error {
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return s.stakeTrie.forEach(func(key, value []byte) error {
+		stake, err := decodeStake(value)
+		if err != nil {
+			return err
+		}
+		return fn(common.Address(key), *stake)
+	})
+}
+
+// returns the stake of addr, loading it from the trie the first time
+func (s *StateDB) getStake(addr common.Address) *Stake {
+	if stake, ok := s.stakes[addr]; ok {
+		return stake
+	}
+	stake := new(Stake)
+	data, err := s.stakeTrie.get(addr.Bytes())
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+	} else if data != nil {
+		if stake, err = decodeStake(data); err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			stake = new(Stake)
+		}
+	}
+	s.stakes[addr] = stake
+	return stake
+}
+
+// returns the stake of addr, the zero stake if it holds none
+func (s *StateDB) GetStake(addr common.Address) Stake {
+	return *s.getStake(addr)
+}
+
+// replaces the stake of addr, a zero stake removes it. the supply is left
+// alone, the caller moves the coins between balance and stake
+func (s *StateDB) SetStake(addr common.Address, stake Stake) {
+	*s.getStake(addr) = stake
+	s.pendingStakes[addr] = struct{}{}
+}
+
+// returns the account at addr, loading it from the trie the first time.
+// an account that does not exist reads as empty
+func (s *StateDB) getAccount(addr common.Address) *Account {
+	if account, ok := s.accounts[addr]; ok {
+		return account
+	}
+	account := new(Account)
+	data, err := s.trie.get(addr.Bytes())
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+	} else if data != nil {
+		if account, err = decodeAccount(data); err != nil {
+			if s.err == nil {
+				s.err = err
+			}
+			account = new(Account)
+		}
+	}
+	s.accounts[addr] = account
+	return account
+}
+
+// returns the account at addr for a change
+func (s *StateDB) getOrNew(addr common.Address) *Account {
+	account := s.getAccount(addr)
+	s.dirty[addr] = struct{}{}
+	s.pending[addr] = struct{}{}
+	return account
+}
+
+func (s *StateDB) GetBalance(addr common.Address) uint64 {
+	return s.getAccount(addr).Balance
+}
+
+func (s *StateDB) AddBalance(addr common.Address, amount uint64) {
+	s.getOrNew(addr).Balance += amount
+}
+
+func (s *StateDB) SubBalance(addr common.Address, amount uint64) error {
+	account := s.getOrNew(addr)
+	if account.Balance < amount {
+		return ErrInsufficientBalance
+	}
+	account.Balance -= amount
+	return nil
+}
+
+func (s *StateDB) GetNonce(addr common.Address) uint64 {
+	return s.getAccount(addr).Nonce
+}
+
+func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	s.getOrNew(addr).Nonce = nonce
+}
+
+// creates new coins in addr's account
+func (s *StateDB) Mint(addr common.Address, amount uint64) {
+	s.AddBalance(addr, amount)
+	s.supply += amount
+}
+
+// destroys coins already taken out of an account
+func (s *StateDB) Burn(amount uint64) {
+	s.supply -= amount
+}
+
+func (s *StateDB) Supply() uint64 {
+	return s.supply
+}
+
+func (s *StateDB) RewardedHeight() uint64 {
+	return s.rewardedHeight
+}
+
+func (s *StateDB) SetRewardedHeight(height uint64) {
+	s.rewardedHeight = height
+}
+
+// writes the accounts changed since the last flush into the trie, in
+// address order so the work does not depend on map order. empty accounts
+// are removed
+func (s *StateDB) flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	addrs := make([]common.Address, 0, len(s.pending))
+	for addr := range s.pending {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+	for _, addr := range addrs {
+		account := s.accounts[addr]
+		var err error
+		if account.empty() {
+			err = s.trie.delete(addr.Bytes())
+		} else {
+			err = s.trie.update(addr.Bytes(), encodeAccount(account))
+		}
+		if err != nil {
+			s.err = err
+			return err
+		}
+	}
+	clear(s.pending)
+
+	addrs = addrs[:0]
+	for addr := range s.pendingStakes {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].Bytes(), addrs[j].Bytes()) < 0
+	})
+	for _, addr := range addrs {
+		stake := s.stakes[addr]
+		var err error
+		if stake.empty() {
+			err = s.stakeTrie.delete(addr.Bytes())
+		} else {
+			err = s.stakeTrie.update(addr.Bytes(), encodeStake(stake))
+		}
+		if err != nil {
+			s.err = err
+			return err
+		}
+	}
+	clear(s.pendingStakes)
+	return nil
+}
+
+func (s *StateDB) rootObject() *rootObject {
+	return &rootObject{
+		Supply:         s.supply,
+		RewardedHeight: s.rewardedHeight,
+		Accounts:       s.trie.hash(),
+		Stakes:         s.stakeTrie.hash(),
+	}
+}
+
+// returns the root the state would be committed under. a state that failed
+// to read its trie has no meaningful root, Error says why
+func (s *StateDB) IntermediateRoot() common.Hash {
+	if err := s.flush(); err != nil {
+		return common.Hash{}
+	}
+	obj := s.rootObject()
+	if obj.Supply == 0 && obj.RewardedHeight == 0 && obj.Accounts == emptyTrie && obj.Stakes == emptyTrie {
+		return EmptyRoot
+	}
+	return common.SHA256(encodeRoot(obj))
+}
+
+// writes the trie nodes built since the state was opened and the root
+// object in one batch and returns the root. the changes count as clean
+// afterwards
+func (s *StateDB) Commit() (common.Hash, error) {
+	root := s.IntermediateRoot()
+	if s.err != nil {
+		return common.Hash{}, s.err
+	}
+	if root == EmptyRoot {
+		clear(s.dirty)
+		return root, nil
+	}
+	batch := s.db.NewBatch()
+	nodes := &prefixWriter{w: batch, prefix: NodePrefix}
+	accounts, err := s.trie.commit(nodes)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	stakes, err := s.stakeTrie.commit(nodes)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	obj := s.rootObject()
+	obj.Accounts, obj.Stakes = accounts, stakes
+	if err := batch.Put(append([]byte(RootPrefix), root.Bytes()...), encodeRoot(obj)); err != nil {
+		return common.Hash{}, err
+	}
+	if err := batch.Write(); err != nil {
+		return common.Hash{}, err
+	}
+	clear(s.dirty)
+	return root, nil
+}
+
+// puts a table prefix in front of the keys written to w, so trie nodes and
+// the root object go into one batch
+type prefixWriter struct {
+	w      nexadb.KeyValueWriter
+	prefix string
+}
+
+func (p *prefixWriter) Put(key []byte, value []byte) error {
+	return p.w.Put(append([]byte(p.prefix), key...), value)
+}
+
+func (p *prefixWriter) Delete(key []byte) error {
+	return p.w.Delete(append([]byte(p.prefix), key...))
+}
+
+// decodes a stored root object, for tools that inspect the database
+func DecodeRoot(data []byte) (any, error) {
+	return decodeRoot(data)
+}
+
+// writes the removal of a state's root object to w. the trie nodes it used
+// stay, as other states may share them, and are left to Prune or
+// DeleteOrphans
+func DeleteRoot(w nexadb.KeyValueWriter, root common.Hash) error {
+	if root == EmptyRoot {
+		return nil
+	}
+	return w.Delete(append([]byte(RootPrefix), root.Bytes()...))
+}
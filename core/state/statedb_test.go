@@ -0,0 +1,153 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+)
+
+// counts the reads and writes that reach the database
+type countingDB struct {
+	nexadb.KeyValueStore
+	gets, puts int
+}
+
+func (db *countingDB) Get(key []byte) ([]byte, error) {
+	db.gets++
+	return db.KeyValueStore.Get(key)
+}
+
+func (db *countingDB) NewBatch() nexadb.Batch {
+	return &countingBatch{Batch: db.KeyValueStore.NewBatch(), db: db}
+}
+
+type countingBatch struct {
+	nexadb.Batch
+	db *countingDB
+}
+
+func (b *countingBatch) Put(key []byte, value []byte) error {
+	b.db.puts++
+	return b.Batch.Put(key, value)
+}
+
+func testAddress(i int) common.Address {
+	return common.Address{byte(i >> 8), byte(i), 0xaa}
+}
+
+// writes a state holding n accounts and returns its root
+func writeTestState(t *testing.T, db nexadb.KeyValueStore, n int) common.Hash {
+	t.Helper()
+	statedb, err := New(EmptyRoot, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		statedb.Mint(testAddress(i), uint64(i+1))
+	}
+	root, err := statedb.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// opening a state and changing one account touches one path, not every
+// account
+func TestStateIncremental(t *testing.T) {
+	db := &countingDB{KeyValueStore: memorydb.New()}
+	root := writeTestState(t, db, 4096)
+
+	db.gets, db.puts = 0, 0
+	statedb, err := New(root, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.gets != 1 {
+		t.Fatalf("opening the state read %d keys, want only the root object", db.gets)
+	}
+	if got := statedb.GetBalance(testAddress(100)); got != 101 {
+		t.Fatalf("balance %d, want 101", got)
+	}
+	statedb.AddBalance(testAddress(100), 1)
+	cpy := statedb.Copy()
+	next, err := statedb.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.gets > 16 || db.puts > 16 {
+		t.Fatalf("changing one account read %d and wrote %d keys", db.gets, db.puts)
+	}
+	if cpy.IntermediateRoot() != next {
+		t.Fatal("the copy does not hash to the committed root")
+	}
+
+	// both states are there and the new one sees the change
+	old, _ := New(root, db)
+	now, _ := New(next, db)
+	if old.GetBalance(testAddress(100)) != 101 || now.GetBalance(testAddress(100)) != 102 {
+		t.Fatal("states do not keep their own balances")
+	}
+	if now.GetBalance(testAddress(4000)) != 4001 {
+		t.Fatal("the new state lost an account it did not change")
+	}
+}
+
+// the root does not depend on the order accounts were changed in, and empty
+// accounts are not part of it
+func TestStateRootOrder(t *testing.T) {
+	a, _ := New(EmptyRoot, memorydb.New())
+	b, _ := New(EmptyRoot, memorydb.New())
+	for i := 0; i < 100; i++ {
+		a.Mint(testAddress(i), 5)
+		b.Mint(testAddress(99-i), 5)
+	}
+	b.Mint(testAddress(500), 1)
+	b.SubBalance(testAddress(500), 1)
+	b.Burn(1)
+	if a.IntermediateRoot() != b.IntermediateRoot() {
+		t.Fatal("roots differ for the same accounts")
+	}
+}
+
+func TestPruneSharedNodes(t *testing.T) {
+	db := memorydb.New()
+	root := writeTestState(t, db, 256)
+
+	statedb, _ := New(root, db)
+	statedb.AddBalance(testAddress(7), 1)
+	next, err := statedb.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roots, nodes, err := Prune(db, map[common.Hash]struct{}{next: {}}, new(PruneGuard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roots != 1 || nodes == 0 {
+		t.Fatalf("pruned %d roots and %d nodes", roots, nodes)
+	}
+	if HasRoot(db, root) {
+		t.Fatal("the old root is still stored")
+	}
+
+	// the kept state still has every node, including those it shared
+	kept, err := New(next, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	err = kept.ForEachAccount(func(addr common.Address, account Account) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 256 {
+		t.Fatalf("walked %d accounts (%v), want 256", count, err)
+	}
+	if kept.GetBalance(testAddress(7)) != 9 {
+		t.Fatalf("balance %d, want 9", kept.GetBalance(testAddress(7)))
+	}
+}
@@ -0,0 +1,618 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+// the accounts of a state live in a hexary patricia trie keyed by address.
+// every node is stored under the hash of its encoding, so states share the
+// subtrees they have in common and a commit only writes the nodes on the
+// paths that changed. keys all have the same length, so values only sit in
+// leaves. the encodings are fixed width:
+//
+//	leaf:      0, path length, path nibbles, value
+//	extension: 1, path length, path nibbles, child hash
+//	branch:    2, 16 bit mask of the children present, their hashes in order
+const (
+	leafTag   = 0
+	extTag    = 1
+	branchTag = 2
+)
+
+// the hash of a trie with no keys
+var emptyTrie = common.Hash{}
+
+type node interface{}
+
+type (
+	// a node that is only known by its hash until a lookup loads it
+	hashNode common.Hash
+
+	leafNode struct {
+		path  []byte // the nibbles of the key below the parent
+		value []byte
+		flags nodeFlags
+	}
+
+	extNode struct {
+		path  []byte // nibbles every key below shares
+		child node
+		flags nodeFlags
+	}
+
+	branchNode struct {
+		children [16]node
+		flags    nodeFlags
+	}
+)
+
+// nodes are never changed once built, an update copies the path it touches.
+// that lets copies of a trie share everything they did not change
+type nodeFlags struct {
+	hash  *common.Hash // set for nodes loaded from or written to the database
+	dirty bool         // built in memory and not written yet
+}
+
+// trie is not safe for concurrent use, but copies of it are independent
+type trie struct {
+	db   nexadb.KeyValueReader
+	root node
+}
+
+func newTrie(root common.Hash, db nexadb.KeyValueReader) *trie {
+	t := &trie{db: db}
+	if root != emptyTrie {
+		t.root = hashNode(root)
+	}
+	return t
+}
+
+// returns an independent trie sharing all nodes with t
+func (t *trie) copy() *trie {
+	return &trie{db: t.db, root: t.root}
+}
+
+// splits a key into nibbles, high nibble first
+func keyNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[2*i] = b >> 4
+		nibbles[2*i+1] = b & 0x0f
+	}
+	return nibbles
+}
+
+// joins nibbles back into a key
+func nibblesKey(nibbles []byte) []byte {
+	key := make([]byte, len(nibbles)/2)
+	for i := range key {
+		key[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+	return key
+}
+
+func prefixLen(a, b []byte) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// loads a node from the database
+func (t *trie) resolve(hash hashNode) (node, error) {
+	data, err := t.db.Get(hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %x", ErrMissingNode, hash[:])
+	}
+	h := common.Hash(hash)
+	return decodeTrieNode(data, &h)
+}
+
+// returns the value stored under key, nil if there is none
+func (t *trie) get(key []byte) ([]byte, error) {
+	n, path := t.root, keyNibbles(key)
+	for {
+		switch cur := n.(type) {
+		case nil:
+			return nil, nil
+		case hashNode:
+			resolved, err := t.resolve(cur)
+			if err != nil {
+				return nil, err
+			}
+			n = resolved
+		case *leafNode:
+			if !bytes.Equal(cur.path, path) {
+				return nil, nil
+			}
+			return cur.value, nil
+		case *extNode:
+			if !bytes.HasPrefix(path, cur.path) {
+				return nil, nil
+			}
+			n, path = cur.child, path[len(cur.path):]
+		case *branchNode:
+			n, path = cur.children[path[0]], path[1:]
+		}
+	}
+}
+
+// stores value under key
+func (t *trie) update(key, value []byte) error {
+	root, err := t.insert(t.root, keyNibbles(key), value)
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+// removes key, a key that is not there is ignored
+func (t *trie) delete(key []byte) error {
+	root, _, err := t.remove(t.root, keyNibbles(key))
+	if err != nil {
+		return err
+	}
+	t.root = root
+	return nil
+}
+
+func (t *trie) insert(n node, path, value []byte) (node, error) {
+	switch cur := n.(type) {
+	case nil:
+		return &leafNode{path: path, value: value, flags: nodeFlags{dirty: true}}, nil
+
+	case hashNode:
+		resolved, err := t.resolve(cur)
+		if err != nil {
+			return nil, err
+		}
+		return t.insert(resolved, path, value)
+
+	case *leafNode:
+		if bytes.Equal(cur.path, path) {
+			if bytes.Equal(cur.value, value) {
+				return cur, nil
+			}
+			return &leafNode{path: path, value: value, flags: nodeFlags{dirty: true}}, nil
+		}
+		// the keys part ways after p nibbles, a branch holds both leaves
+		p := prefixLen(cur.path, path)
+		branch := &branchNode{flags: nodeFlags{dirty: true}}
+		branch.children[cur.path[p]] = &leafNode{path: cur.path[p+1:], value: cur.value, flags: nodeFlags{dirty: true}}
+		branch.children[path[p]] = &leafNode{path: path[p+1:], value: value, flags: nodeFlags{dirty: true}}
+		return wrapExt(path[:p], branch), nil
+
+	case *extNode:
+		p := prefixLen(cur.path, path)
+		if p == len(cur.path) {
+			child, err := t.insert(cur.child, path[p:], value)
+			if err != nil {
+				return nil, err
+			}
+			return &extNode{path: cur.path, child: child, flags: nodeFlags{dirty: true}}, nil
+		}
+		// the key leaves the shared path, split it at a new branch
+		branch := &branchNode{flags: nodeFlags{dirty: true}}
+		branch.children[cur.path[p]] = wrapExt(cur.path[p+1:], cur.child)
+		branch.children[path[p]] = &leafNode{path: path[p+1:], value: value, flags: nodeFlags{dirty: true}}
+		return wrapExt(path[:p], branch), nil
+
+	case *branchNode:
+		child, err := t.insert(cur.children[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		branch := &branchNode{children: cur.children, flags: nodeFlags{dirty: true}}
+		branch.children[path[0]] = child
+		return branch, nil
+	}
+	return nil, fmt.Errorf("unknown trie node %T", n)
+}
+
+// puts child under an extension with path, or returns it as it is if the
+// path is empty
+func wrapExt(path []byte, child node) node {
+	if len(path) == 0 {
+		return child
+	}
+	return &extNode{path: path, child: child, flags: nodeFlags{dirty: true}}
+}
+
+// returns n without the key at path and whether anything changed
+func (t *trie) remove(n node, path []byte) (node, bool, error) {
+	switch cur := n.(type) {
+	case nil:
+		return nil, false, nil
+
+	case hashNode:
+		resolved, err := t.resolve(cur)
+		if err != nil {
+			return nil, false, err
+		}
+		nn, changed, err := t.remove(resolved, path)
+		if !changed || err != nil {
+			return n, false, err
+		}
+		return nn, true, nil
+
+	case *leafNode:
+		if !bytes.Equal(cur.path, path) {
+			return n, false, nil
+		}
+		return nil, true, nil
+
+	case *extNode:
+		if !bytes.HasPrefix(path, cur.path) {
+			return n, false, nil
+		}
+		child, changed, err := t.remove(cur.child, path[len(cur.path):])
+		if !changed || err != nil {
+			return n, false, err
+		}
+		// the child lost a key and may have collapsed into a shorter node,
+		// which takes the extension's path in front of its own
+		switch c := child.(type) {
+		case *leafNode:
+			return &leafNode{path: concat(cur.path, c.path), value: c.value, flags: nodeFlags{dirty: true}}, true, nil
+		case *extNode:
+			return &extNode{path: concat(cur.path, c.path), child: c.child, flags: nodeFlags{dirty: true}}, true, nil
+		default:
+			return &extNode{path: cur.path, child: child, flags: nodeFlags{dirty: true}}, true, nil
+		}
+
+	case *branchNode:
+		child, changed, err := t.remove(cur.children[path[0]], path[1:])
+		if !changed || err != nil {
+			return n, false, err
+		}
+		branch := &branchNode{children: cur.children, flags: nodeFlags{dirty: true}}
+		branch.children[path[0]] = child
+
+		left, pos := 0, 0
+		for i, c := range branch.children {
+			if c != nil {
+				left, pos = left+1, i
+			}
+		}
+		if left > 1 {
+			return branch, true, nil
+		}
+		// a single child is left, it moves up in place of the branch
+		only := branch.children[pos]
+		if h, ok := only.(hashNode); ok {
+			if only, err = t.resolve(h); err != nil {
+				return nil, false, err
+			}
+		}
+		switch c := only.(type) {
+		case *leafNode:
+			return &leafNode{path: concat([]byte{byte(pos)}, c.path), value: c.value, flags: nodeFlags{dirty: true}}, true, nil
+		case *extNode:
+			return &extNode{path: concat([]byte{byte(pos)}, c.path), child: c.child, flags: nodeFlags{dirty: true}}, true, nil
+		default:
+			return &extNode{path: []byte{byte(pos)}, child: only, flags: nodeFlags{dirty: true}}, true, nil
+		}
+	}
+	return nil, false, fmt.Errorf("unknown trie node %T", n)
+}
+
+// returns the root hash of the trie
+func (t *trie) hash() common.Hash {
+	if t.root == nil {
+		return emptyTrie
+	}
+	return hashTrieNode(t.root)
+}
+
+func hashTrieNode(n node) common.Hash {
+	switch cur := n.(type) {
+	case hashNode:
+		return common.Hash(cur)
+	case *leafNode:
+		if cur.flags.hash != nil {
+			return *cur.flags.hash
+		}
+	case *extNode:
+		if cur.flags.hash != nil {
+			return *cur.flags.hash
+		}
+	case *branchNode:
+		if cur.flags.hash != nil {
+			return *cur.flags.hash
+		}
+	}
+	return common.SHA256(encodeTrieNode(n))
+}
+
+func encodeTrieNode(n node) []byte {
+	switch cur := n.(type) {
+	case *leafNode:
+		return encodeLeaf(cur.path, cur.value)
+	case *extNode:
+		return encodeExt(cur.path, hashTrieNode(cur.child))
+	case *branchNode:
+		var hashes [16]common.Hash
+		for i, c := range cur.children {
+			if c != nil {
+				hashes[i] = hashTrieNode(c)
+			}
+		}
+		return encodeBranch(cur, hashes)
+	}
+	return nil
+}
+
+func encodeLeaf(path, value []byte) []byte {
+	return concat([]byte{leafTag, byte(len(path))}, path, value)
+}
+
+func encodeExt(path []byte, child common.Hash) []byte {
+	return concat([]byte{extTag, byte(len(path))}, path, child.Bytes())
+}
+
+// encodes a branch given the hashes of its children
+func encodeBranch(n *branchNode, hashes [16]common.Hash) []byte {
+	enc := make([]byte, 3, 3+16*common.HashLength)
+	enc[0] = branchTag
+	mask := uint16(0)
+	for i, c := range n.children {
+		if c != nil {
+			mask |= 1 << i
+			enc = append(enc, hashes[i].Bytes()...)
+		}
+	}
+	binary.BigEndian.PutUint16(enc[1:], mask)
+	return enc
+}
+
+func decodeTrieNode(data []byte, hash *common.Hash) (node, error) {
+	flags := nodeFlags{hash: hash}
+	if len(data) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	switch data[0] {
+	case leafTag, extTag:
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return nil, ErrInvalidEncoding
+		}
+		path, rest := data[2:2+int(data[1])], data[2+int(data[1]):]
+		for _, nibble := range path {
+			if nibble > 0x0f {
+				return nil, ErrInvalidEncoding
+			}
+		}
+		if data[0] == leafTag {
+			return &leafNode{path: path, value: rest, flags: flags}, nil
+		}
+		if len(rest) != common.HashLength || len(path) == 0 {
+			return nil, ErrInvalidEncoding
+		}
+		return &extNode{path: path, child: hashNode(rest), flags: flags}, nil
+	case branchTag:
+		if len(data) < 3 {
+			return nil, ErrInvalidEncoding
+		}
+		mask, rest := binary.BigEndian.Uint16(data[1:]), data[3:]
+		branch := &branchNode{flags: flags}
+		for i := range branch.children {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			if len(rest) < common.HashLength {
+				return nil, ErrInvalidEncoding
+			}
+			branch.children[i] = hashNode(rest[:common.HashLength])
+			rest = rest[common.HashLength:]
+		}
+		if len(rest) != 0 {
+			return nil, ErrInvalidEncoding
+		}
+		return branch, nil
+	}
+	return nil, ErrInvalidEncoding
+}
+
+// writes every node built since the trie was opened to w and returns the
+// root hash. the trie keeps only the root hash afterwards, the nodes are
+// loaded again when a lookup needs them
+func (t *trie) commit(w nexadb.KeyValueWriter) (common.Hash, error) {
+	if t.root == nil {
+		return emptyTrie, nil
+	}
+	hash, err := commitTrieNode(t.root, w)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	t.root = hashNode(hash)
+	return hash, nil
+}
+
+func commitTrieNode(n node, w nexadb.KeyValueWriter) (common.Hash, error) {
+	var enc []byte
+	switch cur := n.(type) {
+	case hashNode:
+		return common.Hash(cur), nil
+	case *leafNode:
+		if !cur.flags.dirty {
+			return hashTrieNode(cur), nil
+		}
+		enc = encodeLeaf(cur.path, cur.value)
+	case *extNode:
+		if !cur.flags.dirty {
+			return hashTrieNode(cur), nil
+		}
+		child, err := commitTrieNode(cur.child, w)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		enc = encodeExt(cur.path, child)
+	case *branchNode:
+		if !cur.flags.dirty {
+			return hashTrieNode(cur), nil
+		}
+		var hashes [16]common.Hash
+		for i, c := range cur.children {
+			if c == nil {
+				continue
+			}
+			hash, err := commitTrieNode(c, w)
+			if err != nil {
+				return common.Hash{}, err
+			}
+			hashes[i] = hash
+		}
+		enc = encodeBranch(cur, hashes)
+	default:
+		return common.Hash{}, fmt.Errorf("unknown trie node %T", n)
+	}
+	hash := common.SHA256(enc)
+	return hash, w.Put(hash.Bytes(), enc)
+}
+
+// calls fn for every key and value in key order. it stops at the first
+// error fn returns
+func (t *trie) forEach(fn func(key, value []byte) error) error {
+	return t.walk(t.root, nil, fn)
+}
+
+func (t *trie) walk(n node, path []byte, fn func(key, value []byte) error) error {
+	switch cur := n.(type) {
+	case nil:
+		return nil
+	case hashNode:
+		resolved, err := t.resolve(cur)
+		if err != nil {
+			return err
+		}
+		return t.walk(resolved, path, fn)
+	case *leafNode:
+		return fn(nibblesKey(concat(path, cur.path)), cur.value)
+	case *extNode:
+		return t.walk(cur.child, concat(path, cur.path), fn)
+	case *branchNode:
+		for i, c := range cur.children {
+			if err := t.walk(c, concat(path, []byte{byte(i)}), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// adds the hash of every node of the stored trie at root to marked. a
+// subtree whose root is marked already is skipped, as it was walked before.
+// so is a node that is not stored, nothing below it can be reached
+func markTrie(db nexadb.KeyValueReader, root common.Hash, marked map[common.Hash]struct{}) error {
+	if root == emptyTrie {
+		return nil
+	}
+	if _, ok := marked[root]; ok {
+		return nil
+	}
+	data, err := db.Get(root.Bytes())
+	if err != nil {
+		return nil
+	}
+	n, err := decodeTrieNode(data, nil)
+	if err != nil {
+		return err
+	}
+	marked[root] = struct{}{}
+	switch cur := n.(type) {
+	case *extNode:
+		return markTrie(db, common.Hash(cur.child.(hashNode)), marked)
+	case *branchNode:
+		for _, c := range cur.children {
+			if c == nil {
+				continue
+			}
+			if err := markTrie(db, common.Hash(c.(hashNode)), marked); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// a stored trie node as inspection tools show it
+type trieNodeInfo struct {
+	Kind     string
+	Path     string        // nibbles in hex
+	Value    any           `json:",omitempty"`
+	Children []common.Hash `json:",omitempty"`
+}
+
+// decodes a stored trie node, for tools that inspect the database
+func DecodeNode(data []byte) (any, error) {
+	n, err := decodeTrieNode(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	nibbles := func(path []byte) string {
+		out := make([]byte, len(path))
+		for i, nibble := range path {
+			out[i] = "0123456789abcdef"[nibble]
+		}
+		return string(out)
+	}
+	switch cur := n.(type) {
+	case *leafNode:
+		info := &trieNodeInfo{Kind: "leaf", Path: nibbles(cur.path), Value: cur.value}
+		if account, err := decodeAccount(cur.value); err == nil {
+			info.Value = account
+		}
+		return info, nil
+	case *extNode:
+		return &trieNodeInfo{Kind: "extension", Path: nibbles(cur.path), Children: []common.Hash{common.Hash(cur.child.(hashNode))}}, nil
+	case *branchNode:
+		info := &trieNodeInfo{Kind: "branch"}
+		for _, c := range cur.children {
+			if c != nil {
+				info.Children = append(info.Children, common.Hash(c.(hashNode)))
+			}
+		}
+		return info, nil
+	}
+	return nil, ErrInvalidEncoding
+}
@@ -0,0 +1,157 @@
+package state
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+)
+
+func randomKey(rng *rand.Rand) []byte {
+	key := make([]byte, common.AddressLength)
+	rng.Read(key)
+	// keys sharing long prefixes build extensions and deep branches
+	if rng.Intn(2) == 0 {
+		key[0], key[1] = 0xab, 0xcd
+	}
+	return key
+}
+
+// checks the trie against a map after random updates and deletes, and that
+// the root only depends on the contents
+func TestTrieRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	db := memorydb.New()
+	tr := newTrie(emptyTrie, db)
+	model := make(map[string][]byte)
+
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 50; i++ {
+			key := randomKey(rng)
+			if len(model) > 0 && rng.Intn(3) == 0 {
+				// delete one that is there
+				for k := range model {
+					key = []byte(k)
+					break
+				}
+				if err := tr.delete(key); err != nil {
+					t.Fatal(err)
+				}
+				delete(model, string(key))
+				continue
+			}
+			value := []byte{byte(rng.Intn(256)), byte(round)}
+			if err := tr.update(key, value); err != nil {
+				t.Fatal(err)
+			}
+			model[string(key)] = value
+		}
+		root, err := tr.commit(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a trie built from scratch in another order has the same root
+		fresh := newTrie(emptyTrie, memorydb.New())
+		keys := make([]string, 0, len(model))
+		for k := range model {
+			keys = append(keys, k)
+		}
+		rng.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, k := range keys {
+			fresh.update([]byte(k), model[k])
+		}
+		if fresh.hash() != root {
+			t.Fatalf("round %d: root %x, rebuilt trie has %x", round, root, fresh.hash())
+		}
+
+		// a trie opened at the root reads everything back in key order
+		reopened := newTrie(root, db)
+		for k, v := range model {
+			got, err := reopened.get([]byte(k))
+			if err != nil || !bytes.Equal(got, v) {
+				t.Fatalf("round %d: key %x is %x (%v), want %x", round, k, got, err, v)
+			}
+		}
+		sort.Strings(keys)
+		var walked []string
+		err = reopened.forEach(func(key, value []byte) error {
+			walked = append(walked, string(key))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(walked) != len(keys) {
+			t.Fatalf("round %d: walked %d keys, want %d", round, len(walked), len(keys))
+		}
+		for i := range keys {
+			if walked[i] != keys[i] {
+				t.Fatalf("round %d: key %d out of order", round, i)
+			}
+		}
+	}
+
+	// removing everything leaves the empty trie
+	for k := range model {
+		if err := tr.delete([]byte(k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tr.hash() != emptyTrie {
+		t.Fatalf("emptied trie has root %x", tr.hash())
+	}
+}
+
+// copies share nodes but not changes
+func TestTrieCopy(t *testing.T) {
+	db := memorydb.New()
+	tr := newTrie(emptyTrie, db)
+	tr.update([]byte{1}, []byte{1})
+	tr.update([]byte{2}, []byte{2})
+	root, _ := tr.commit(db)
+
+	a, b := newTrie(root, db), newTrie(root, db)
+	cpy := a.copy()
+	cpy.update([]byte{1}, []byte{9})
+	cpy.delete([]byte{2})
+
+	if got, _ := a.get([]byte{1}); !bytes.Equal(got, []byte{1}) {
+		t.Fatalf("original sees the copy's update: %x", got)
+	}
+	if got, _ := a.get([]byte{2}); !bytes.Equal(got, []byte{2}) {
+		t.Fatalf("original sees the copy's delete: %x", got)
+	}
+	if a.hash() != b.hash() || cpy.hash() == a.hash() {
+		t.Fatal("copy changed the root of the original")
+	}
+}
+
+func TestMarkTrie(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	db := memorydb.New()
+	tr := newTrie(emptyTrie, db)
+	for i := 0; i < 200; i++ {
+		tr.update(randomKey(rng), []byte{byte(i)})
+	}
+	root, err := tr.commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marked := make(map[common.Hash]struct{})
+	if err := markTrie(db, root, marked); err != nil {
+		t.Fatal(err)
+	}
+	it := db.NewIterator(nil)
+	defer it.Release()
+	stored := 0
+	for it.Next() {
+		stored++
+	}
+	if len(marked) != stored {
+		t.Fatalf("marked %d nodes, %d are stored", len(marked), stored)
+	}
+}
@@ -0,0 +1,323 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// opens the state with the given root, served from the state cache when it
+// was used recently. the caller gets its own copy to modify. a pruned node
+// checks a cached state is still stored, as a prune may have deleted it
+func (chain *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	if cached, ok := chain.stateCache.Get(root); ok {
+		if chain.pruner != nil && !state.HasRoot(chain.Database, root) {
+			return nil, state.ErrMissingRoot
+		}
+		return cached.Copy(), nil
+	}
+	statedb, err := state.New(root, chain.Database)
+	if err != nil {
+		return nil, err
+	}
+	chain.stateCache.Add(root, statedb.Copy())
+	return statedb, nil
+}
+
+// opens the state a block is applied on top of
+func (chain *BlockChain) ParentState(b *types.Block) (*state.StateDB, error) {
+	root, err := chain.parentRoot(b)
+	if err != nil {
+		return nil, err
+	}
+	return chain.StateAt(root)
+}
+
+// returns the state root b builds on
+func (chain *BlockChain) parentRoot(b *types.Block) (common.Hash, error) {
+	if b.ParentHash == GenesisParentHash {
+		return chain.genesisRoot, nil
+	}
+	parent := chain.GetHeader(b.ParentHash)
+	if parent == nil {
+		return common.Hash{}, ErrUnknownParent
+	}
+	return parent.StateRoot, nil
+}
+
+// returns the state at the head of the chain
+func (chain *BlockChain) State() (*state.StateDB, error) {
+	head, err := chain.Last()
+	if err != nil {
+		return chain.StateAt(chain.genesisRoot)
+	}
+	return chain.StateAt(head.StateRoot)
+}
+
+// makes the state changes that come before a block's transactions: the
+// first block of an epoch settles the stake changes of the epoch before
+func (chain *BlockChain) beginBlock(statedb *state.StateDB, b *types.Block) error {
+	if chain.Validators.startsEpoch(b.Height) {
+		return applyEpochStakes(statedb)
+	}
+	return nil
+}
+
+// settles the epoch's stakes, runs every transaction, pays out the fees and
+// then the block reward
+func (chain *BlockChain) applyBlock(statedb *state.StateDB, b *types.Block) (*types.BlockReceipts, error) {
+	author, err := chain.Engine.Author(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := chain.beginBlock(statedb, b); err != nil {
+		return nil, err
+	}
+
+	receipts := &types.BlockReceipts{}
+	baseFees, tips, gasUsed := uint64(0), uint64(0), uint64(0)
+	for _, tx := range b.Transactions {
+		receipt, err := applyTransaction(statedb, tx, b.BaseFee)
+		if err != nil {
+			// a trie read that failed shows up as a missing balance, report
+			// the real cause
+			if dbErr := statedb.Error(); dbErr != nil {
+				return nil, dbErr
+			}
+			return nil, err
+		}
+		if gasUsed += receipt.GasUsed; gasUsed > b.GasLimit {
+			return nil, ErrGasLimitReached
+		}
+		baseFees += receipt.BaseFee
+		tips += receipt.Tip
+		receipts.Receipts = append(receipts.Receipts, receipt)
+	}
+
+	// the base fee is always burned, only tips are split
+	statedb.Burn(baseFees)
+	receipts.Fees = distributeFees(chain.Config.Fees, statedb, author, tips)
+	receipts.Fees.BaseFee = baseFees
+	receipts.Fees.Collected += baseFees
+
+	if err := chain.applyRewards(statedb, b); err != nil {
+		return nil, err
+	}
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// charges the sender for the gas the transaction used at the base fee plus
+// tip and then does what the transaction type says: moves the amount to the
+// recipient, stakes it or unstakes. the sender must be able to pay for the
+// whole gas limit up front. if the rest cannot be done, such as an amount
+// that cannot be covered on top of the gas, the transaction still goes in
+// the block, with a failed receipt and only the gas charged. the sender has to have signed
+// the transaction, and its nonce has to be the sender's account nonce so it
+// cannot be replayed
+func applyTransaction(statedb *state.StateDB, tx *types.Transaction, baseFee uint64) (*types.Receipt, error) {
+	if err := verifyTxSender(tx); err != nil {
+		return nil, err
+	}
+	if tx.Nonce != statedb.GetNonce(tx.Sender) {
+		return nil, ErrTxInvalidNonce
+	}
+	if tx.Amount < 0 {
+		return nil, ErrNegativeAmount
+	}
+	gasUsed, err := IntrinsicGas(tx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.GasLimit < gasUsed {
+		return nil, ErrIntrinsicGas
+	}
+	tip, ok := tx.EffectiveTip(baseFee)
+	if !ok {
+		return nil, ErrFeeCapTooLow
+	}
+
+	amount := uint64(tx.Amount)
+	upfront, err := gasCost(tx.GasLimit, baseFee+tip)
+	if err != nil {
+		return nil, err
+	}
+	if statedb.GetBalance(tx.Sender) < upfront {
+		return nil, state.ErrInsufficientBalance
+	}
+
+	burned, err := gasCost(gasUsed, baseFee)
+	if err != nil {
+		return nil, err
+	}
+	tipped, err := gasCost(gasUsed, tip)
+	if err != nil {
+		return nil, err
+	}
+	if err := statedb.SubBalance(tx.Sender, burned+tipped); err != nil {
+		return nil, err
+	}
+	switch tx.Type {
+	case types.StakeTx:
+		err = stake(statedb, tx.Sender, amount, tx.RandaoCommit)
+	case types.UnstakeTx:
+		err = unstake(statedb, tx.Sender)
+	default:
+		if err = statedb.SubBalance(tx.Sender, amount); err == nil {
+			statedb.AddBalance(tx.Recipient, amount)
+		}
+	}
+	status := types.ReceiptStatusSuccessful
+	if err != nil {
+		status = types.ReceiptStatusFailed
+	}
+	statedb.SetNonce(tx.Sender, statedb.GetNonce(tx.Sender)+1)
+	return &types.Receipt{
+		TxHash:  tx.Hash,
+		Status:  status,
+		GasUsed: gasUsed,
+		Fee:     burned + tipped,
+		BaseFee: burned,
+		Tip:     tipped,
+	}, nil
+}
+
+// checks the transaction is signed by the key of its sender
+func verifyTxSender(tx *types.Transaction) error {
+	if wallet.PubKeyToAddress(tx.PublicKey) != tx.Sender ||
+		!wallet.VerifySignature(tx.PublicKey, tx.SigningHash(), tx.Signature) {
+		return ErrTxInvalidSig
+	}
+	return nil
+}
+
+// signs the transaction with the sender's wallet and fills in its hash
+func SignTx(tx *types.Transaction, w *wallet.Wallet) error {
+	if w.Address != tx.Sender {
+		return ErrTxInvalidSig
+	}
+	tx.PublicKey = w.PublicKeyBytes()
+	sig, err := w.Sign(tx.SigningHash())
+	if err != nil {
+		return err
+	}
+	tx.Signature = sig
+	tx.Hash = tx.ComputeHash()
+	return nil
+}
+
+// runs the block's state transition and records the resulting state root.
+// it has to be called after the engine prepared the block and before it is sealed
+func (chain *BlockChain) Assemble(b *types.Block) error {
+	parent := chain.GetBlock(b.ParentHash)
+	b.BaseFee = CalcBaseFee(chain.Config.FeeMarket, parent)
+	if b.GasLimit == 0 {
+		b.GasLimit = chain.nextGasLimit(parent)
+	}
+	b.TxHash = types.DeriveTxHash(b.Transactions)
+
+	parentRoot, err := chain.parentRoot(b)
+	if err != nil {
+		return err
+	}
+	statedb, err := chain.StateAt(parentRoot)
+	if err != nil {
+		return err
+	}
+	receipts, err := chain.applyBlock(statedb, b)
+	if err != nil {
+		return err
+	}
+	b.GasUsed = receipts.GasUsed()
+	b.StateRoot = statedb.IntermediateRoot()
+	return nil
+}
+
+// re-runs the block's state transition, checks it ends at the block's state
+// root and commits the new state. the receipts are written to db, which the
+// caller writes out with the block, and returned
+func (chain *BlockChain) processBlock(db nexadb.KeyValueWriter, b *types.Block) (*types.BlockReceipts, error) {
+	if b.TxHash != types.DeriveTxHash(b.Transactions) {
+		return nil, ErrInvalidTxHash
+	}
+	// lookups and the tx hash commitment are keyed by the hash a transaction
+	// claims, so it has to be the real one
+	for _, tx := range b.Transactions {
+		if tx.Hash != tx.ComputeHash() {
+			return nil, ErrTxHashMismatch
+		}
+	}
+	if err := chain.verifyGasLimit(b); err != nil {
+		return nil, err
+	}
+	if err := chain.verifyFeeMarket(b); err != nil {
+		return nil, err
+	}
+	unlock := chain.lockState()
+	defer unlock()
+
+	parentRoot, err := chain.parentRoot(b)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := chain.StateAt(parentRoot)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := chain.applyBlock(statedb, b)
+	if err != nil {
+		return nil, err
+	}
+	if receipts.GasUsed() != b.GasUsed {
+		return nil, ErrInvalidGasUsed
+	}
+	if statedb.IntermediateRoot() != b.StateRoot {
+		return nil, ErrInvalidStateRoot
+	}
+	changed := statedb.DirtyAccounts()
+	root, err := chain.commitState(statedb)
+	if err != nil {
+		return nil, err
+	}
+	chain.stateCache.Add(root, statedb)
+	chain.updateSnapshot(root, parentRoot, statedb, changed)
+	for i, receipt := range receipts.Receipts {
+		receipt.BlockHash = b.Hash
+		receipt.BlockHeight = b.Height
+		receipt.Index = uint64(i)
+	}
+	if err := db.Put(receiptsKey(b.Hash), receipts.BytesStream()); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
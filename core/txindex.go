@@ -0,0 +1,102 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+// indexes every transaction of a canonical block by hash, writing the
+// entries to db
+func (chain *BlockChain) writeTxLookups(db nexadb.KeyValueWriter, b *types.Block) error {
+	for i, tx := range b.Transactions {
+		lookup := &types.TxLookup{
+			BlockHash:   b.Hash,
+			BlockHeight: b.Height,
+			Index:       uint64(i),
+		}
+		if err := db.Put(txLookupKey(tx.Hash), lookup.BytesStream()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drops the index entries that point into b. a transaction that is also in
+// the new canonical chain gets its entry written again afterwards
+func (chain *BlockChain) deleteTxLookups(db nexadb.KeyValueWriter, b *types.Block) error {
+	for _, tx := range b.Transactions {
+		lookup, err := chain.GetTxLookup(tx.Hash)
+		if err != nil || lookup.BlockHash != b.Hash {
+			continue
+		}
+		if err := db.Delete(txLookupKey(tx.Hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns where a transaction sits in the canonical chain
+func (chain *BlockChain) GetTxLookup(hash common.Hash) (*types.TxLookup, error) {
+	data, err := chain.Database.Get(txLookupKey(hash))
+	if err != nil {
+		return nil, ErrTxNotFound
+	}
+	return types.DecodeTxLookupBytesStream(data), nil
+}
+
+// returns a canonical transaction and where it sits in the chain
+func (chain *BlockChain) GetTransaction(hash common.Hash) (*types.Transaction, *types.TxLookup, error) {
+	lookup, err := chain.GetTxLookup(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := chain.GetBlock(lookup.BlockHash)
+	if b == nil || lookup.Index >= uint64(len(b.Transactions)) {
+		return nil, nil, ErrTxNotFound
+	}
+	return b.Transactions[lookup.Index], lookup, nil
+}
+
+// returns the receipt of a canonical transaction
+func (chain *BlockChain) GetReceipt(hash common.Hash) (*types.Receipt, error) {
+	lookup, err := chain.GetTxLookup(hash)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := chain.GetBlockReceipts(lookup.BlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if lookup.Index >= uint64(len(receipts.Receipts)) {
+		return nil, ErrReceiptsNotFound
+	}
+	return receipts.Receipts[lookup.Index], nil
+}
@@ -0,0 +1,360 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package core
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/event"
+)
+
+var (
+	ErrTxPoolKnownTx  = errors.New("transaction is already in the pool")
+	ErrTxPoolBadHash  = errors.New("transaction hash does not match its contents")
+	ErrTxPoolIncluded = errors.New("transaction is already in the canonical chain")
+	ErrTxPoolStopped  = errors.New("transaction pool is stopped")
+	ErrTxPoolNonceLow = errors.New("transaction nonce was already used by its sender")
+	ErrTxPoolFull     = errors.New("transaction pool is full and the transaction does not outbid any in it")
+)
+
+// most transactions the pool holds. once it is full a transaction only
+// gets in by evicting one that tips less
+const MaxTxPoolSize = 4096
+
+// TxPool holds transactions waiting to be put in a block. it follows the
+// chain: transactions that make it into a canonical block are dropped, and
+// transactions from blocks a reorg removes come back.
+type TxPool struct {
+	chain *BlockChain
+
+	lock sync.RWMutex
+	all  map[common.Hash]*types.Transaction
+
+	txFeed event.Feed[NewTxsEvent]
+
+	headSub  *event.Subscription[ChainHeadEvent]
+	reorgSub *event.Subscription[ReorgEvent]
+	wg       sync.WaitGroup
+	stopped  bool
+}
+
+func NewTxPool(chain *BlockChain) *TxPool {
+	pool := &TxPool{
+		chain:    chain,
+		all:      make(map[common.Hash]*types.Transaction),
+		headSub:  chain.SubscribeChainHeadEvent(0),
+		reorgSub: chain.SubscribeReorgEvent(0),
+	}
+	pool.wg.Add(1)
+	go pool.loop()
+	return pool
+}
+
+// follows chain events until the pool is stopped
+func (pool *TxPool) loop() {
+	defer pool.wg.Done()
+
+	heads, reorgs := pool.headSub.Chan(), pool.reorgSub.Chan()
+	for heads != nil || reorgs != nil {
+		select {
+		case _, ok := <-heads:
+			if !ok {
+				heads = nil
+				continue
+			}
+			pool.dropIncluded()
+		case ev, ok := <-reorgs:
+			if !ok {
+				reorgs = nil
+				continue
+			}
+			pool.readd(ev.OldChain)
+			pool.dropIncluded()
+		}
+	}
+}
+
+// follows reorgs again after the feed ended the subscription because the
+// pool fell behind. the transactions of the reorgs it missed are lost, the
+// senders have to send them again. returns nil once the pool is stopped
+func (pool *TxPool) resubscribeReorgs() <-chan ReorgEvent {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	err := pool.reorgSub.Err()
+	if pool.stopped || err == nil {
+		return nil
+	}
+	slog.Warn("transaction pool missed reorgs", "err", err)
+	pool.reorgSub = pool.chain.SubscribeReorgEvent(0)
+	return pool.reorgSub.Chan()
+}
+
+// removes every transaction that is now in the canonical chain or whose
+// nonce its sender has used up. it checks the index and the head state
+// rather than the head block so that missed events do not matter
+func (pool *TxPool) dropIncluded() {
+	statedb, _ := pool.chain.State()
+
+	pool.lock.RLock()
+	var drop []common.Hash
+	for hash, tx := range pool.all {
+		if statedb != nil && tx.Nonce < statedb.GetNonce(tx.Sender) {
+			drop = append(drop, hash)
+		}
+	}
+	pool.lock.RUnlock()
+
+	// the index is read without the lock, a transaction added meanwhile is
+	// checked against it when it is added
+	for _, hash := range pool.hashes() {
+		if _, err := pool.chain.GetTxLookup(hash); err == nil {
+			drop = append(drop, hash)
+		}
+	}
+	pool.Remove(drop...)
+}
+
+// returns the hashes of every pooled transaction
+func (pool *TxPool) hashes() []common.Hash {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+	hashes := make([]common.Hash, 0, len(pool.all))
+	for hash := range pool.all {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// puts the transactions of blocks that left the canonical chain back
+func (pool *TxPool) readd(blocks []*types.Block) {
+	var txs []*types.Transaction
+	for _, b := range blocks {
+		txs = append(txs, b.Transactions...)
+	}
+	pool.Add(txs...)
+}
+
+// checks the transaction could go in the block after head, whose state is
+// statedb. a nil head is the empty chain
+func (pool *TxPool) validate(tx *types.Transaction, head *types.Block, statedb *state.StateDB) error {
+	if tx.ComputeHash() != tx.Hash {
+		return ErrTxPoolBadHash
+	}
+	if err := verifyTxSender(tx); err != nil {
+		return err
+	}
+	if tx.Amount < 0 {
+		return ErrNegativeAmount
+	}
+	gas, err := IntrinsicGas(tx)
+	if err != nil {
+		return err
+	}
+	if tx.GasLimit < gas {
+		return ErrIntrinsicGas
+	}
+	maxFee, _ := tx.FeeCaps()
+	if maxFee < CalcBaseFee(pool.chain.Config.FeeMarket, head) {
+		return ErrFeeCapTooLow
+	}
+	if _, err := pool.chain.GetTxLookup(tx.Hash); err == nil {
+		return ErrTxPoolIncluded
+	}
+	if statedb == nil {
+		return nil
+	}
+	if tx.Nonce < statedb.GetNonce(tx.Sender) {
+		return ErrTxPoolNonceLow
+	}
+	// the sender has to afford the whole gas limit at the fee cap
+	cost, err := gasCost(tx.GasLimit, maxFee)
+	if err != nil {
+		return err
+	}
+	if amount := uint64(tx.Amount); cost+amount < cost || statedb.GetBalance(tx.Sender) < cost+amount {
+		return state.ErrInsufficientBalance
+	}
+	return nil
+}
+
+// returns the pooled transaction that tips the least, the newest of those
+// that tip the same. the caller must hold the lock
+func (pool *TxPool) cheapest() *types.Transaction {
+	var cheapest *types.Transaction
+	for _, tx := range pool.all {
+		if cheapest == nil {
+			cheapest = tx
+			continue
+		}
+		_, tip := tx.FeeCaps()
+		_, low := cheapest.FeeCaps()
+		if tip < low || (tip == low && tx.Time > cheapest.Time) {
+			cheapest = tx
+		}
+	}
+	return cheapest
+}
+
+// adds transactions to the pool and posts a NewTxsEvent for the ones that
+// were accepted. the returned errors line up with txs, nil where accepted
+func (pool *TxPool) Add(txs ...*types.Transaction) []error {
+	errs := make([]error, len(txs))
+	var added []*types.Transaction
+
+	// the checks read the database, so they run before the lock is taken
+	head := pool.chain.CurrentBlock()
+	var statedb *state.StateDB
+	if head != nil {
+		statedb, _ = pool.chain.StateAt(head.StateRoot)
+	} else {
+		statedb, _ = pool.chain.State()
+	}
+	for i, tx := range txs {
+		errs[i] = pool.validate(tx, head, statedb)
+	}
+
+	pool.lock.Lock()
+	for i, tx := range txs {
+		if errs[i] != nil {
+			continue
+		}
+		if pool.stopped {
+			errs[i] = ErrTxPoolStopped
+			continue
+		}
+		if _, ok := pool.all[tx.Hash]; ok {
+			errs[i] = ErrTxPoolKnownTx
+			continue
+		}
+		if len(pool.all) >= MaxTxPoolSize {
+			cheapest := pool.cheapest()
+			_, tip := tx.FeeCaps()
+			if _, low := cheapest.FeeCaps(); tip <= low {
+				errs[i] = ErrTxPoolFull
+				continue
+			}
+			delete(pool.all, cheapest.Hash)
+		}
+		pool.all[tx.Hash] = tx
+		added = append(added, tx)
+	}
+	pool.lock.Unlock()
+
+	if len(added) > 0 {
+		pool.txFeed.Send(NewTxsEvent{Txs: added})
+	}
+	return errs
+}
+
+// returns a pooled transaction by hash
+func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+	return pool.all[hash]
+}
+
+// drops transactions from the pool
+func (pool *TxPool) Remove(hashes ...common.Hash) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	for _, hash := range hashes {
+		delete(pool.all, hash)
+	}
+}
+
+// returns the number of pooled transactions
+func (pool *TxPool) Len() int {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+	return len(pool.all)
+}
+
+// returns every pooled transaction, highest tip first and oldest first
+// among equal tips, which is the order a producer should pack them in. the
+// transactions of one sender fill the places they got in nonce order, as
+// they only apply that way
+func (pool *TxPool) Pending() []*types.Transaction {
+	pool.lock.RLock()
+	txs := make([]*types.Transaction, 0, len(pool.all))
+	for _, tx := range pool.all {
+		txs = append(txs, tx)
+	}
+	pool.lock.RUnlock()
+
+	sort.Slice(txs, func(i, j int) bool {
+		_, tipI := txs[i].FeeCaps()
+		_, tipJ := txs[j].FeeCaps()
+		if tipI != tipJ {
+			return tipI > tipJ
+		}
+		return txs[i].Time < txs[j].Time
+	})
+
+	places := make(map[common.Address][]int)
+	for i, tx := range txs {
+		places[tx.Sender] = append(places[tx.Sender], i)
+	}
+	for _, idx := range places {
+		if len(idx) < 2 {
+			continue
+		}
+		sent := make([]*types.Transaction, len(idx))
+		for j, i := range idx {
+			sent[j] = txs[i]
+		}
+		sort.Slice(sent, func(a, b int) bool { return sent[a].Nonce < sent[b].Nonce })
+		for j, i := range idx {
+			txs[i] = sent[j]
+		}
+	}
+	return txs
+}
+
+// subscribes to transactions entering the pool, buffer <= 0 uses the default buffer
+func (pool *TxPool) SubscribeNewTxsEvent(buffer int) *event.Subscription[NewTxsEvent] {
+	return pool.txFeed.Subscribe(buffer)
+}
+
+// stops following the chain and refuses new transactions
+func (pool *TxPool) Stop() {
+	pool.lock.Lock()
+	pool.stopped = true
+	pool.lock.Unlock()
+
+	pool.headSub.Unsubscribe()
+	pool.lock.RLock()
+	pool.reorgSub.Unsubscribe()
+	pool.lock.RUnlock()
+	pool.wg.Wait()
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+	"github.com/PulseCoinOrg/nexacoin/core/state"
+	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
+	"github.com/PulseCoinOrg/nexacoin/wallet"
+)
+
+// the pool turns away transactions the next block could not take: a fee
+// cap under its base fee, or a sender who cannot pay for gas and amount
+func TestTxPoolValidate(t *testing.T) {
+	w := setupTestWallet(t)
+	chain := newTestChain(t, "db")
+	// the validator's wallet earns its first balance with the block reward
+	insertTestBlock(t, chain)
+	pool := NewTxPool(chain)
+	t.Cleanup(pool.Stop)
+
+	signed := func(w *wallet.Wallet, nonce uint64, amount int64, fee uint64) *types.Transaction {
+		tx := types.NewTx(nonce, time.Now().Unix(), w.Address, common.Address{1}, amount, params.TxGasTransfer, fee)
+		if err := SignTx(tx, w); err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := statedb.GetBalance(w.Address)
+	broke, err := wallet.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tx   *types.Transaction
+		err  error
+	}{
+		{"fee cap under the base fee", signed(w, 0, 1, 10), ErrFeeCapTooLow},
+		{"sender without balance", signed(broke, 0, 1, 2000), state.ErrInsufficientBalance},
+		{"amount over the balance", signed(w, 0, int64(balance), 2000), state.ErrInsufficientBalance},
+		{"affordable transfer", signed(w, 0, 1, 2000), nil},
+		{"same transfer again", nil, ErrTxPoolKnownTx},
+	}
+	tests[4].tx = tests[3].tx
+	for _, tt := range tests {
+		if err := pool.Add(tt.tx)[0]; !errors.Is(err, tt.err) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.err)
+		}
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("pool holds %d transactions, want 1", pool.Len())
+	}
+}
@@ -28,6 +28,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"fmt"
 
@@ -45,22 +46,117 @@ type Block struct {
 	UncleHash    common.Hash // A hash of a block that is valid but not chosen to be apart of the chain
 	Transactions []*Transaction
 	TxHash       common.Hash
-	Height       int
-	// Nonce        int
-	// TODO Gas uint64 add this
+	StateRoot    common.Hash // root of the account state after this block
+	BaseFee      uint64      // fee per gas every transaction in the block pays and burns
+	GasLimit     uint64      // most gas the transactions of the block may use
+	GasUsed      uint64      // gas the transactions of the block used
+	Height       uint64
+	Proposer     common.Address
+	RandaoReveal common.Hash // preimage of the proposer's last randao commitment
+	RandaoMix    common.Hash // accumulated randomness up to and including this block
+	Difficulty   uint64      // proof of work only, the hash must be below 2^256 / Difficulty
+	Nonce        uint64
+	Vote         common.Address // proof of authority only, signer proposed for addition or removal
+	VoteAdd      bool           // true to vote Vote in, false to vote it out
+	PublicKey    []byte         // key of the signer that sealed the block
+	Signature    []byte         // signature over SealHash
+
+	// an earlier block's commit certificate, its voters share in this
+	// block's reward
+	Certificate *CommitCertificate
 }
 
-func NewBlock(time int64, parentHash common.Hash, transactions []*Transaction) *Block {
+func NewBlock(height uint64, time int64, parentHash common.Hash, transactions []*Transaction) *Block {
 	block := &Block{
+		Height:       height,
 		Time:         time,
 		ParentHash:   parentHash,
 		Transactions: transactions,
 	}
-	block.Hash = common.SHA256(block.BytesStream())
+	block.TxHash = DeriveTxHash(transactions)
+	block.Hash = block.ComputeHash()
+	return block
+}
+
+// returns a copy of the block without its transactions that shares no
+// memory with b
+func (b *Block) CopyHeader() *Block {
+	cpy := *b
+	cpy.Transactions = nil
+	cpy.PublicKey = bytes.Clone(b.PublicKey)
+	cpy.Signature = bytes.Clone(b.Signature)
+	if b.Certificate != nil {
+		cpy.Certificate = b.Certificate.Copy()
+	}
+	return &cpy
+}
+
+// hashes the hashes of a block's transactions in order
+func DeriveTxHash(transactions []*Transaction) common.Hash {
 	if len(transactions) == 0 {
-		block.TxHash = NoTxHash
+		return NoTxHash
 	}
-	return block
+	var buf bytes.Buffer
+	for _, tx := range transactions {
+		buf.Write(tx.Hash.Bytes())
+	}
+	return common.SHA256(buf.Bytes())
+}
+
+// hashes every field of the block except the hash itself. the
+// transactions are covered through TxHash
+func (b *Block) ComputeHash() common.Hash {
+	return common.SHA256(b.encodeHeader(true))
+}
+
+// hashes the block without its hash or seal signature, this is what a
+// signer signs when sealing
+func (b *Block) SealHash() common.Hash {
+	return common.SHA256(b.encodeHeader(false))
+}
+
+// writes the header fields at a fixed width for hashing. gob is not used
+// because its output depends on what else the process encoded before
+func (b *Block) encodeHeader(withSignature bool) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, b.Time)
+	buf.Write(b.ParentHash.Bytes())
+	buf.Write(b.UncleHash.Bytes())
+	buf.Write(b.TxHash.Bytes())
+	buf.Write(b.StateRoot.Bytes())
+	binary.Write(&buf, binary.BigEndian, b.BaseFee)
+	binary.Write(&buf, binary.BigEndian, b.GasLimit)
+	binary.Write(&buf, binary.BigEndian, b.GasUsed)
+	binary.Write(&buf, binary.BigEndian, b.Height)
+	buf.Write(b.Proposer.Bytes())
+	buf.Write(b.RandaoReveal.Bytes())
+	buf.Write(b.RandaoMix.Bytes())
+	binary.Write(&buf, binary.BigEndian, b.Difficulty)
+	binary.Write(&buf, binary.BigEndian, b.Nonce)
+	buf.Write(b.Vote.Bytes())
+	binary.Write(&buf, binary.BigEndian, b.VoteAdd)
+	writeBytes(&buf, b.PublicKey)
+	if withSignature {
+		writeBytes(&buf, b.Signature)
+	}
+	if b.Certificate == nil {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+		buf.Write(b.Certificate.Hash().Bytes())
+	}
+	return buf.Bytes()
+}
+
+// writes data behind its length so neighbouring fields cannot run together
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
+
+// folds a proposer's reveal into the parent's randomness
+func MixRandao(parentMix common.Hash, reveal common.Hash) common.Hash {
+	return common.SHA256(append(parentMix.Bytes(), reveal.Bytes()...))
 }
 
 // converts the block into bytes
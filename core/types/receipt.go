@@ -0,0 +1,139 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+)
+
+const (
+	// the transaction ran but its transfer did not go through, gas was still charged
+	ReceiptStatusFailed uint64 = iota
+	ReceiptStatusSuccessful
+)
+
+// the outcome of a single transaction in a block
+type Receipt struct {
+	TxHash  common.Hash
+	Status  uint64
+	GasUsed uint64
+	Fee     uint64 // total paid by the sender
+	BaseFee uint64 // part of Fee that was burned
+	Tip     uint64 // part of Fee that went through the fee split
+
+	BlockHash   common.Hash
+	BlockHeight uint64
+	Index       uint64 // position of the transaction in the block
+}
+
+// where a transaction ended up in the canonical chain
+type TxLookup struct {
+	BlockHash   common.Hash
+	BlockHeight uint64
+	Index       uint64
+}
+
+// where the fees of a block went
+type FeeTotals struct {
+	Collected  uint64
+	BaseFee    uint64 // burned on top of Burned
+	Tips       uint64 // what the split below is taken from
+	ToProposer uint64
+	Burned     uint64
+	ToTreasury uint64
+}
+
+// everything the state transition records about a block
+type BlockReceipts struct {
+	Fees     FeeTotals
+	Receipts []*Receipt
+}
+
+func (r *BlockReceipts) Copy() *BlockReceipts {
+	cpy := &BlockReceipts{Fees: r.Fees}
+	if r.Receipts != nil {
+		cpy.Receipts = make([]*Receipt, len(r.Receipts))
+		for i, receipt := range r.Receipts {
+			rc := *receipt
+			cpy.Receipts[i] = &rc
+		}
+	}
+	return cpy
+}
+
+// returns the gas used by every transaction of the block together
+func (r *BlockReceipts) GasUsed() uint64 {
+	total := uint64(0)
+	for _, receipt := range r.Receipts {
+		total += receipt.GasUsed
+	}
+	return total
+}
+
+// converts the lookup into bytes
+func (l *TxLookup) BytesStream() []byte {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(l); err != nil {
+		fmt.Println(err)
+	}
+	return buf.Bytes()
+}
+
+// converts the bytes of a lookup into a lookup
+func DecodeTxLookupBytesStream(data []byte) *TxLookup {
+	var lookup TxLookup
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&lookup); err != nil {
+		fmt.Println(err)
+	}
+	return &lookup
+}
+
+// converts the receipts into bytes
+func (r *BlockReceipts) BytesStream() []byte {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(r); err != nil {
+		fmt.Println(err)
+	}
+	return buf.Bytes()
+}
+
+// converts the bytes of block receipts into block receipts
+func DecodeReceiptsBytesStream(data []byte) *BlockReceipts {
+	var receipts BlockReceipts
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&receipts); err != nil {
+		fmt.Println(err)
+	}
+	return &receipts
+}
@@ -2,33 +2,195 @@ package types
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"fmt"
 
 	"github.com/PulseCoinOrg/nexacoin/common"
 )
 
+// what a transaction does, which decides how much gas it costs
+type TxType uint8
+
+const (
+	TransferTx TxType = iota // moves Amount from Sender to Recipient
+	StakeTx                  // locks Amount of Sender's balance as validator stake
+	UnstakeTx                // pays Sender's whole stake back at the next epoch
+)
+
 type Transaction struct {
+	Type      TxType
 	Time      int64
-	Fee       uint64
+	GasLimit  uint64 // most gas the transaction may use
+	Fee       uint64 // flat fee per gas of a legacy transaction, used when MaxFee is zero
+	MaxFee    uint64 // most the sender will pay per gas, base fee plus tip
+	Tip       uint64 // paid per gas on top of the base fee to the proposer
 	Sender    common.Address
 	Recipient common.Address
 	Amount    int64
-	Hash      common.Hash
+	Nonce     uint64 // number of transactions the sender sent before this one
+	// end of the hash onion a staking sender reveals its randao values
+	// from, only used by the sender's first stake
+	RandaoCommit common.Hash
+	PublicKey    []byte // key of the sender, it has to derive to Sender
+	Signature    []byte // signature over SigningHash
+	Hash         common.Hash
 }
 
 func NewTx(
+	nonce uint64,
+	time int64,
+	sender common.Address,
+	recipient common.Address,
+	amount int64,
+	gasLimit uint64,
+	fee uint64,
+) *Transaction {
+	tx := &Transaction{
+		Type:      TransferTx,
+		Time:      time,
+		GasLimit:  gasLimit,
+		Fee:       fee,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    amount,
+		Nonce:     nonce,
+	}
+	tx.Hash = tx.ComputeHash()
+	return tx
+}
+
+// creates a transaction that pays the block's base fee plus tip per gas,
+// but never more than maxFee per gas in total
+func NewDynamicFeeTx(
+	nonce uint64,
 	time int64,
 	sender common.Address,
 	recipient common.Address,
 	amount int64,
+	gasLimit uint64,
+	maxFee uint64,
+	tip uint64,
 ) *Transaction {
-	return &Transaction{
+	tx := &Transaction{
+		Type:      TransferTx,
 		Time:      time,
+		GasLimit:  gasLimit,
+		MaxFee:    maxFee,
+		Tip:       tip,
 		Sender:    sender,
 		Recipient: recipient,
 		Amount:    amount,
+		Nonce:     nonce,
+	}
+	tx.Hash = tx.ComputeHash()
+	return tx
+}
+
+// creates a transaction that stakes amount of the sender's balance. commit
+// is registered if the sender does not hold stake yet
+func NewStakeTx(
+	nonce uint64,
+	time int64,
+	sender common.Address,
+	amount int64,
+	commit common.Hash,
+	gasLimit uint64,
+	maxFee uint64,
+	tip uint64,
+) *Transaction {
+	tx := &Transaction{
+		Type:         StakeTx,
+		Time:         time,
+		GasLimit:     gasLimit,
+		MaxFee:       maxFee,
+		Tip:          tip,
+		Sender:       sender,
+		Amount:       amount,
+		Nonce:        nonce,
+		RandaoCommit: commit,
+	}
+	tx.Hash = tx.ComputeHash()
+	return tx
+}
+
+// creates a transaction that pays the sender's stake back once the current
+// epoch ends
+func NewUnstakeTx(
+	nonce uint64,
+	time int64,
+	sender common.Address,
+	gasLimit uint64,
+	maxFee uint64,
+	tip uint64,
+) *Transaction {
+	tx := &Transaction{
+		Type:     UnstakeTx,
+		Time:     time,
+		GasLimit: gasLimit,
+		MaxFee:   maxFee,
+		Tip:      tip,
+		Sender:   sender,
+		Nonce:    nonce,
+	}
+	tx.Hash = tx.ComputeHash()
+	return tx
+}
+
+// returns the fee cap and tip of the transaction. a legacy flat fee is
+// treated as both
+func (tx *Transaction) FeeCaps() (maxFee uint64, tip uint64) {
+	if tx.MaxFee == 0 {
+		return tx.Fee, tx.Fee
+	}
+	return tx.MaxFee, tx.Tip
+}
+
+// returns the tip the transaction actually pays under baseFee, and false if
+// it cannot afford the base fee at all
+func (tx *Transaction) EffectiveTip(baseFee uint64) (uint64, bool) {
+	maxFee, tip := tx.FeeCaps()
+	if maxFee < baseFee {
+		return 0, false
 	}
+	return min(tip, maxFee-baseFee), true
+}
+
+// hashes every field of the transaction except the hash itself
+func (tx *Transaction) ComputeHash() common.Hash {
+	return common.SHA256(tx.encode(true))
+}
+
+// hashes the transaction without its hash or signature, this is what the
+// sender signs
+func (tx *Transaction) SigningHash() common.Hash {
+	return common.SHA256(tx.encode(false))
+}
+
+// writes the fields at a fixed width for hashing rather than gob encoding
+// them, whose output depends on what else the process encoded before
+func (tx *Transaction) encode(withSignature bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(tx.Type))
+	binary.Write(&buf, binary.BigEndian, tx.Time)
+	binary.Write(&buf, binary.BigEndian, tx.GasLimit)
+	binary.Write(&buf, binary.BigEndian, tx.Fee)
+	binary.Write(&buf, binary.BigEndian, tx.MaxFee)
+	binary.Write(&buf, binary.BigEndian, tx.Tip)
+	buf.Write(tx.Sender.Bytes())
+	buf.Write(tx.Recipient.Bytes())
+	binary.Write(&buf, binary.BigEndian, tx.Amount)
+	binary.Write(&buf, binary.BigEndian, tx.Nonce)
+	// only stakes carry a commitment, so other transactions keep the
+	// hashes they always had
+	if tx.Type == StakeTx {
+		buf.Write(tx.RandaoCommit.Bytes())
+	}
+	writeBytes(&buf, tx.PublicKey)
+	if withSignature {
+		writeBytes(&buf, tx.Signature)
+	}
+	return buf.Bytes()
 }
 
 // converts the transaction into bytes
@@ -41,6 +203,41 @@ func (tx *Transaction) BytesStream() []byte {
 	return buf.Bytes()
 }
 
+// returns copies of the transactions in a new slice
+func CopyTxs(txs []*Transaction) []*Transaction {
+	if txs == nil {
+		return nil
+	}
+	cpy := make([]*Transaction, len(txs))
+	for i, tx := range txs {
+		t := *tx
+		t.PublicKey = bytes.Clone(tx.PublicKey)
+		t.Signature = bytes.Clone(tx.Signature)
+		cpy[i] = &t
+	}
+	return cpy
+}
+
+// converts a list of transactions, such as a block body, into bytes
+func TxsBytesStream(txs []*Transaction) []byte {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(txs); err != nil {
+		fmt.Println(err)
+	}
+	return buf.Bytes()
+}
+
+// converts the bytes of a list of transactions back into the list
+func DecodeTxsBytesStream(data []byte) []*Transaction {
+	var txs []*Transaction
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&txs); err != nil {
+		fmt.Println(err)
+	}
+	return txs
+}
+
 // converts transaction bytes into a transaction
 func DecodeTxBytesStream(data []byte) *Transaction {
 	var tx Transaction
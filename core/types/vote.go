@@ -0,0 +1,139 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+)
+
+type VoteType uint8
+
+const (
+	Prevote VoteType = iota
+	Precommit
+)
+
+func (t VoteType) String() string {
+	switch t {
+	case Prevote:
+		return "prevote"
+	case Precommit:
+		return "precommit"
+	}
+	return "unknown"
+}
+
+// a validator's signed statement about a block at a height. a height that
+// does not finalize in one round is voted on again in the next, so a
+// validator votes once per height and round
+type Vote struct {
+	Type      VoteType
+	Height    uint64
+	Round     uint64
+	BlockHash common.Hash
+	Validator common.Address
+	PublicKey []byte
+	Signature []byte
+}
+
+func (v *Vote) Copy() *Vote {
+	cpy := *v
+	cpy.PublicKey = bytes.Clone(v.PublicKey)
+	cpy.Signature = bytes.Clone(v.Signature)
+	return &cpy
+}
+
+// the hash a validator signs, which covers everything except the signature
+func (v *Vote) SigningHash() common.Hash {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(v.Type))
+	binary.Write(&buf, binary.BigEndian, v.Height)
+	binary.Write(&buf, binary.BigEndian, v.Round)
+	buf.Write(v.BlockHash.Bytes())
+	buf.Write(v.Validator.Bytes())
+	return common.SHA256(buf.Bytes())
+}
+
+// proof that a block was finalized: precommits of one round from more than
+// 2/3 of the stake
+type CommitCertificate struct {
+	Height     uint64
+	Round      uint64
+	BlockHash  common.Hash
+	Precommits []*Vote
+}
+
+func (c *CommitCertificate) Copy() *CommitCertificate {
+	cpy := *c
+	if c.Precommits != nil {
+		cpy.Precommits = make([]*Vote, len(c.Precommits))
+		for i, vote := range c.Precommits {
+			cpy.Precommits[i] = vote.Copy()
+		}
+	}
+	return &cpy
+}
+
+// hashes the certificate together with every precommit and its signature
+func (c *CommitCertificate) Hash() common.Hash {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, c.Height)
+	binary.Write(&buf, binary.BigEndian, c.Round)
+	buf.Write(c.BlockHash.Bytes())
+	binary.Write(&buf, binary.BigEndian, uint32(len(c.Precommits)))
+	for _, vote := range c.Precommits {
+		buf.Write(vote.SigningHash().Bytes())
+		writeBytes(&buf, vote.PublicKey)
+		writeBytes(&buf, vote.Signature)
+	}
+	return common.SHA256(buf.Bytes())
+}
+
+// converts the certificate into bytes
+func (c *CommitCertificate) BytesStream() []byte {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(c); err != nil {
+		fmt.Println(err)
+	}
+	return buf.Bytes()
+}
+
+// converts the bytes of a certificate into a certificate
+func DecodeCertificateBytesStream(data []byte) *CommitCertificate {
+	var cert CommitCertificate
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&cert); err != nil {
+		fmt.Println(err)
+	}
+	return &cert
+}
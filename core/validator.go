@@ -4,7 +4,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/PulseCoinOrg/nexacoin/common"
 	"github.com/PulseCoinOrg/nexacoin/core/types"
+	"github.com/PulseCoinOrg/nexacoin/params"
 	"github.com/PulseCoinOrg/nexacoin/wallet"
 )
 
@@ -12,18 +14,59 @@ type Validator struct {
 	Wallet          *wallet.Wallet
 	CurrentBlock    *types.Block
 	ValidatedBlocks []*types.Block
+
+	// the public end of the validator's hash onion, registered with the
+	// validator's first stake. its first block reveals the preimage of this value and
+	// every later one the preimage of its previous reveal, so the chain
+	// alone says which reveal is due next
+	RandaoCommit common.Hash
+	onion        []common.Hash
 }
 
 func NewValidator() (*Validator, error) {
-	var validated []*types.Block
 	wallet, err := wallet.LoadFromDisk()
 	if err != nil {
 		return nil, err
 	}
+	return newValidator(wallet), nil
+}
+
+// makes a validator of w, with the hash onion its key derives
+func newValidator(w *wallet.Wallet) *Validator {
+	var validated []*types.Block
+	onion := newRandaoOnion(randaoSecret(w), params.RandaoOnionLength)
 	return &Validator{
-		Wallet:          wallet,
+		Wallet:          w,
 		ValidatedBlocks: validated,
-	}, nil
+		RandaoCommit:    onion[len(onion)-1],
+		onion:           onion,
+	}
+}
+
+// the onion secret is derived from the wallet key, so a validator that is
+// restarted with its wallet can still open the commitment the chain has
+func randaoSecret(w *wallet.Wallet) common.Hash {
+	return common.SHA256(append([]byte("nexacoin randao onion"), w.PrivateKey...))
+}
+
+// builds a hash chain from secret, the last element is the commitment
+func newRandaoOnion(secret common.Hash, length int) []common.Hash {
+	onion := make([]common.Hash, length+1)
+	onion[0] = secret
+	for i := 1; i < len(onion); i++ {
+		onion[i] = common.SHA256(onion[i-1].Bytes())
+	}
+	return onion
+}
+
+// returns the preimage of commit in the validator's hash onion
+func (v *Validator) RevealFor(commit common.Hash) (common.Hash, error) {
+	for i := len(v.onion) - 1; i > 0; i-- {
+		if v.onion[i] == commit {
+			return v.onion[i-1], nil
+		}
+	}
+	return common.Hash{}, ErrRandaoExhausted
 }
 
 func (v *Validator) GetValidatorAddress() (string, error) {
@@ -33,6 +76,15 @@ func (v *Validator) GetValidatorAddress() (string, error) {
 	return v.Wallet.Address.Hex(), nil
 }
 
+// returns the genesis entry that starts the chain with v holding stake
+func (v *Validator) GenesisValidator(stake uint64) params.GenesisValidator {
+	return params.GenesisValidator{
+		Address:      v.Wallet.Address,
+		Stake:        stake,
+		RandaoCommit: v.RandaoCommit,
+	}
+}
+
 func (v *Validator) ValidateBlock(b *types.Block) bool {
 	if b == nil {
 		return false
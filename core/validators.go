@@ -1,58 +1,174 @@
 package core
 
 import (
+	"encoding/binary"
 	"errors"
-	"fmt"
 	"math/big"
 
 	"github.com/PulseCoinOrg/nexacoin/common"
-	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+	"github.com/PulseCoinOrg/nexacoin/common/lru"
+	"github.com/PulseCoinOrg/nexacoin/params"
 )
 
 var (
-	ErrNoValidators = errors.New("no validators")
+	ErrNoValidators      = errors.New("no validators")
+	ErrUnknownValidator  = errors.New("validator is not part of the pool")
+	ErrEpochNotScheduled = errors.New("no proposer schedule for epoch")
 )
 
+// ActiveValidator is a member of an epoch's validator set, as the state
+// the epoch builds on records it
+type ActiveValidator struct {
+	Address      common.Address
+	Stake        uint64
+	RandaoCommit common.Hash // commitment registered with the first stake
+}
+
+// ValidatorPool works out the validator set and proposer schedule of each
+// epoch. the sets themselves are chain state, the pool only keeps the
+// transitions it worked out for recent epochs. it is safe for concurrent use
 type ValidatorPool struct {
-	Database          *memorydb.Database
-	Validators        map[string]*Validator
-	SelectedValidator *Validator
+	EpochLength uint64
+
+	transitions *lru.Cache[epochKey, *epochTransition]
+}
+
+// number of epoch transitions, of every branch, kept in memory
+const epochTransitions = 64
+
+// an epoch on one branch. the branch is named by the block the epoch's
+// first block builds on
+type epochKey struct {
+	epoch  uint64
+	anchor common.Hash
 }
 
 func NewValidatorPool() *ValidatorPool {
-	db := memorydb.New()
-	validators := make(map[string]*Validator)
+	return NewValidatorPoolWithEpoch(params.DefaultEpochLength)
+}
+
+func NewValidatorPoolWithEpoch(epochLength uint64) *ValidatorPool {
+	if epochLength == 0 {
+		epochLength = params.DefaultEpochLength
+	}
 	return &ValidatorPool{
-		Database:   db,
-		Validators: validators,
+		EpochLength: epochLength,
+		transitions: lru.New[epochKey, *epochTransition](epochTransitions),
 	}
 }
 
-func (vp *ValidatorPool) AddValidator(v *Validator) error {
-	if vp.Validators == nil {
-		return fmt.Errorf("validators is nil")
+// returns the epoch a block height belongs to
+func (vp *ValidatorPool) EpochOf(height uint64) uint64 {
+	return height / vp.EpochLength
+}
+
+// returns the height of the first block of an epoch. the chain starts at
+// height 1, so that is where the first epoch starts
+func (vp *ValidatorPool) epochStart(epoch uint64) uint64 {
+	return max(epoch*vp.EpochLength, 1)
+}
+
+// reports whether the block at height is the first of its epoch
+func (vp *ValidatorPool) startsEpoch(height uint64) bool {
+	return height == vp.epochStart(vp.EpochOf(height))
+}
+
+// the validator set and proposer schedule of an epoch on one branch
+type epochTransition struct {
+	epoch      uint64
+	anchor     common.Hash // block the epoch's first block builds on
+	seed       []byte
+	validators []*ActiveValidator // in address order
+	schedule   []common.Address
+}
+
+// returns the member of the set with the given address, nil if there is
+// none
+func (t *epochTransition) validator(address common.Address) *ActiveValidator {
+	for _, v := range t.validators {
+		if v.Address == address {
+			return v
+		}
 	}
-	vp.Validators[v.Wallet.Address.Hex()] = v
 	return nil
 }
 
-func (vp *ValidatorPool) SelectValidator(seed []byte) (common.Address, error) {
-	if len(vp.Validators) == 0 {
-		return common.Address{}, ErrNoValidators
+// returns the proposer the transition schedules for a block height
+func (t *epochTransition) proposerAt(height uint64) common.Address {
+	return t.schedule[height%uint64(len(t.schedule))]
+}
+
+// returns the stake of the whole set
+func (t *epochTransition) totalWeight() *big.Int {
+	total := new(big.Int)
+	for _, v := range t.validators {
+		total.Add(total, new(big.Int).SetUint64(v.Stake))
 	}
+	return total
+}
 
-	var validatorList []*Validator
-	for _, v := range vp.Validators {
-		validatorList = append(validatorList, v)
+// works out the transition into epoch on the branch of anchor. the set is
+// read by load and the schedule seeded by seed, both have to be the same on
+// every node, so they are taken from the chain. the result is worked out
+// once per epoch and branch and every block of the epoch on that branch is
+// checked against it
+func (vp *ValidatorPool) prepareTransition(epoch uint64, anchor common.Hash, seed []byte, load func() ([]*ActiveValidator, error)) (*epochTransition, error) {
+	key := epochKey{epoch: epoch, anchor: anchor}
+	if t, ok := vp.transitions.Get(key); ok {
+		return t, nil
+	}
+	validators, err := load()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := vp.computeSchedule(validators, epoch, seed)
+	if err != nil {
+		return nil, err
+	}
+	t := &epochTransition{
+		epoch:      epoch,
+		anchor:     anchor,
+		seed:       seed,
+		validators: validators,
+		schedule:   schedule,
+	}
+	vp.transitions.Add(key, t)
+	return t, nil
+}
+
+// picks a validator weighted by stake using the hash of the seed
+func (vp *ValidatorPool) pick(validatorList []*ActiveValidator, seed []byte) *ActiveValidator {
+	total := new(big.Int)
+	for _, v := range validatorList {
+		total.Add(total, new(big.Int).SetUint64(v.Stake))
 	}
 
 	hash := common.SHA256(seed)
 	randNum := new(big.Int).SetBytes(hash.Bytes())
+	target := randNum.Mod(randNum, total)
 
-	selectedIndex := randNum.Mod(randNum, big.NewInt(int64(len(validatorList)))).Int64()
-	selected := validatorList[selectedIndex]
+	for _, v := range validatorList {
+		w := new(big.Int).SetUint64(v.Stake)
+		if target.Cmp(w) < 0 {
+			return v
+		}
+		target.Sub(target, w)
+	}
+	return validatorList[len(validatorList)-1]
+}
 
-	vp.SelectedValidator = selected
+func (vp *ValidatorPool) computeSchedule(validatorList []*ActiveValidator, epoch uint64, seed []byte) ([]common.Address, error) {
+	if len(validatorList) == 0 {
+		return nil, ErrNoValidators
+	}
 
-	return selected.Wallet.Address, nil
+	schedule := make([]common.Address, vp.EpochLength)
+	slotSeed := make([]byte, len(seed)+16)
+	copy(slotSeed, seed)
+	binary.BigEndian.PutUint64(slotSeed[len(seed):], epoch)
+	for slot := range schedule {
+		binary.BigEndian.PutUint64(slotSeed[len(seed)+8:], uint64(slot))
+		schedule[slot] = vp.pick(validatorList, slotSeed).Address
+	}
+	return schedule, nil
 }
@@ -0,0 +1,205 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package event
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// buffer given to subscriptions that do not ask for one
+const DefaultBuffer = 64
+
+// most values a queued subscription holds for a subscriber that is not
+// reading
+const MaxQueued = 1024
+
+var ErrQueueOverflow = errors.New("subscriber fell too far behind and was unsubscribed")
+
+// Feed delivers values of one type to any number of subscribers. sending
+// never blocks: a subscriber whose buffer is full misses the value, and
+// the miss is counted on its subscription. a queued subscriber instead
+// gets every value, at the cost of holding them until it catches up. one
+// that falls more than MaxQueued values behind is unsubscribed rather than
+// left to hold values forever, Err tells it why its channel was closed.
+type Feed[T any] struct {
+	lock sync.Mutex
+	subs map[*Subscription[T]]struct{}
+}
+
+type Subscription[T any] struct {
+	feed    *Feed[T]
+	ch      chan T
+	ended   bool // guarded by the feed lock
+	dropped atomic.Uint64
+	err     atomic.Pointer[error]
+
+	// a queued subscription collects values in queue, guarded by the feed
+	// lock, and forward moves them on to ch
+	queued bool
+	queue  []T
+	wake   chan struct{}
+	quit   chan struct{}
+}
+
+// registers a new subscriber with a channel holding up to buffer values
+func (f *Feed[T]) Subscribe(buffer int) *Subscription[T] {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	sub := &Subscription[T]{feed: f, ch: make(chan T, buffer)}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.subs == nil {
+		f.subs = make(map[*Subscription[T]]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// registers a subscriber that never misses a value: what does not fit in
+// its channel of buffer values is queued until it is read. the subscription
+// ends with ErrQueueOverflow once more than MaxQueued values wait
+func (f *Feed[T]) SubscribeQueued(buffer int) *Subscription[T] {
+	sub := f.Subscribe(buffer)
+	f.lock.Lock()
+	sub.queued = true
+	sub.wake = make(chan struct{}, 1)
+	sub.quit = make(chan struct{})
+	f.lock.Unlock()
+
+	go sub.forward()
+	return sub
+}
+
+// moves queued values into the channel until the subscription ends
+func (s *Subscription[T]) forward() {
+	defer close(s.ch)
+	for {
+		s.feed.lock.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.feed.lock.Unlock()
+
+		for _, value := range queue {
+			select {
+			case s.ch <- value:
+			case <-s.quit:
+				return
+			}
+		}
+		select {
+		case <-s.wake:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// hands value to every subscriber with room for it and returns how many got it
+func (f *Feed[T]) Send(value T) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	sent := 0
+	for sub := range f.subs {
+		if sub.queued {
+			if len(sub.queue) >= MaxQueued {
+				err := ErrQueueOverflow
+				sub.err.Store(&err)
+				sub.end()
+				continue
+			}
+			sub.queue = append(sub.queue, value)
+			select {
+			case sub.wake <- struct{}{}:
+			default:
+			}
+			sent++
+			continue
+		}
+		select {
+		case sub.ch <- value:
+			sent++
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+	return sent
+}
+
+// returns the number of subscribers
+func (f *Feed[T]) Len() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.subs)
+}
+
+// the channel values are delivered on, it is closed on Unsubscribe
+func (s *Subscription[T]) Chan() <-chan T {
+	return s.ch
+}
+
+// returns how many values were missed because the channel was full, always
+// zero for a queued subscription
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// returns why the feed ended the subscription, nil while it runs or if the
+// subscriber ended it
+func (s *Subscription[T]) Err() error {
+	if err := s.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// stops delivery and closes the channel. it is safe to call more than once
+func (s *Subscription[T]) Unsubscribe() {
+	s.feed.lock.Lock()
+	defer s.feed.lock.Unlock()
+	s.end()
+}
+
+// removes the subscription from the feed and closes its channel, the caller
+// must hold the feed lock
+func (s *Subscription[T]) end() {
+	if s.ended {
+		return
+	}
+	s.ended = true
+	delete(s.feed.subs, s)
+	if s.queued {
+		// forward closes the channel once it stops
+		close(s.quit)
+		return
+	}
+	close(s.ch)
+}
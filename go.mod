@@ -4,8 +4,7 @@ go 1.24.4
 
 require (
 	github.com/btcsuite/btcutil v1.0.2
+	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db
 	github.com/syndtr/goleveldb v1.0.0
 	golang.org/x/crypto v0.39.0
 )
-
-require github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db // indirect
@@ -0,0 +1,107 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+// Package nexadb defines the interfaces the chain's key-value stores share.
+package nexadb
+
+// reads values by key
+type KeyValueReader interface {
+	// reports whether a key is present
+	Has(key []byte) (bool, error)
+
+	// retrieves the value stored under key
+	Get(key []byte) ([]byte, error)
+}
+
+// changes values by key
+type KeyValueWriter interface {
+	// stores value under key
+	Put(key []byte, value []byte) error
+
+	// removes key
+	Delete(key []byte) error
+}
+
+// walks over stored keys in key order
+type Iteratee interface {
+	// returns an iterator over every key starting with prefix. the iterator
+	// must be released once the caller is done with it
+	NewIterator(prefix []byte) Iterator
+}
+
+// Iterator steps through a range of keys in ascending order. it starts in
+// front of the first key, so Next has to be called before Key and Value.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Batch collects writes in memory and applies them to the store all at
+// once, so a crash never leaves only some of them on disk.
+type Batch interface {
+	KeyValueWriter
+
+	// returns the number of bytes queued so far
+	ValueSize() int
+
+	// applies the queued writes to the store
+	Write() error
+
+	// drops the queued writes so the batch can be reused
+	Reset()
+}
+
+// creates write batches
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// Snapshot is a frozen view of a store. writes made to the store after the
+// snapshot was taken are not visible through it.
+type Snapshot interface {
+	KeyValueReader
+	Iteratee
+
+	// releases the snapshot, it cannot be used afterwards
+	Release()
+}
+
+// takes snapshots
+type Snapshotter interface {
+	NewSnapshot() (Snapshot, error)
+}
+
+// KeyValueStore is everything the chain needs from a database.
+type KeyValueStore interface {
+	KeyValueReader
+	KeyValueWriter
+	Iteratee
+	Batcher
+	Snapshotter
+}
@@ -0,0 +1,216 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package freezer
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+var (
+	ErrOutOfBounds   = errors.New("freezer item does not exist")
+	ErrUnknownTable  = errors.New("freezer table does not exist")
+	ErrAppendOrder   = errors.New("freezer items must be appended in order")
+	ErrMissingItem   = errors.New("freezer append is missing an item for a table")
+	ErrReadOnly      = errors.New("freezer is opened read-only")
+	errFreezerClosed = errors.New("freezer is closed")
+)
+
+// Freezer is an append-only store of immutable items numbered from zero.
+// it holds several tables that always contain the same number of items,
+// so item n of every table belongs together. it is safe for concurrent use.
+type Freezer struct {
+	lock     sync.RWMutex
+	tables   map[string]*table
+	items    uint64
+	readOnly bool
+}
+
+// opens or creates a freezer in dir with the given tables. if a crash left
+// the tables at different lengths they are all cut back to the shortest
+func New(dir string, tables []string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return open(dir, tables, false)
+}
+
+// opens the freezer in dir for reading only, so it can be read while
+// another process appends to it. nothing on disk is changed and only the
+// items every table held completely at the time are visible. a freezer
+// that does not exist yet opens empty
+func NewReadOnly(dir string, tables []string) (*Freezer, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return &Freezer{tables: make(map[string]*table), readOnly: true}, nil
+	}
+	return open(dir, tables, true)
+}
+
+func open(dir string, tables []string, readOnly bool) (*Freezer, error) {
+	f := &Freezer{tables: make(map[string]*table, len(tables)), readOnly: readOnly}
+	for _, name := range tables {
+		t, err := openTable(dir, name, readOnly)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = t
+	}
+
+	f.items = ^uint64(0)
+	for _, t := range f.tables {
+		f.items = min(f.items, t.items)
+	}
+	if len(f.tables) == 0 {
+		f.items = 0
+	}
+	if readOnly {
+		return f, nil
+	}
+	for _, t := range f.tables {
+		if err := t.truncateItems(f.items); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// returns the number of items in the freezer
+func (f *Freezer) Items() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.items
+}
+
+// returns item number of the named table
+func (f *Freezer) Retrieve(name string, number uint64) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.tables == nil {
+		return nil, errFreezerClosed
+	}
+	t, ok := f.tables[name]
+	if !ok {
+		return nil, ErrUnknownTable
+	}
+	if number >= f.items {
+		return nil, ErrOutOfBounds
+	}
+	return t.retrieve(number)
+}
+
+// appends item number to every table. items maps each table name to its
+// item and number has to be the current item count
+func (f *Freezer) Append(number uint64, items map[string][]byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.tables == nil {
+		return errFreezerClosed
+	}
+	if f.readOnly {
+		return ErrReadOnly
+	}
+	if number != f.items {
+		return ErrAppendOrder
+	}
+	for name := range f.tables {
+		if _, ok := items[name]; !ok {
+			return ErrMissingItem
+		}
+	}
+	for name, t := range f.tables {
+		if err := t.append(items[name]); err != nil {
+			// put the tables that did take the item back in line
+			for _, t := range f.tables {
+				t.truncateItems(f.items)
+			}
+			return err
+		}
+	}
+	f.items++
+	return nil
+}
+
+// drops every item from number items on, keeping the first items items.
+// a read-only freezer leaves the disk alone and only stops showing them
+func (f *Freezer) Truncate(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.tables == nil {
+		return errFreezerClosed
+	}
+	if items >= f.items {
+		return nil
+	}
+	if f.readOnly {
+		f.items = items
+		return nil
+	}
+	for _, t := range f.tables {
+		if err := t.truncateItems(items); err != nil {
+			return err
+		}
+	}
+	f.items = items
+	return nil
+}
+
+// flushes every table to disk
+func (f *Freezer) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readOnly {
+		return nil
+	}
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closes every table, the freezer cannot be used afterwards
+func (f *Freezer) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var err error
+	for _, t := range f.tables {
+		if cerr := t.close(); err == nil {
+			err = cerr
+		}
+	}
+	f.tables = nil
+	return err
+}
@@ -0,0 +1,133 @@
+package freezer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// appends n items numbered from the freezer's item count to every table
+func appendItems(t *testing.T, f *Freezer, tables []string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		number := f.Items()
+		items := make(map[string][]byte)
+		for _, name := range tables {
+			items[name] = []byte(fmt.Sprintf("%s-%d", name, number))
+		}
+		if err := f.Append(number, items); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFreezerAppendRetrieve(t *testing.T) {
+	dir := t.TempDir()
+	tables := []string{"headers", "bodies"}
+	f, err := New(dir, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendItems(t, f, tables, 5)
+
+	if err := f.Append(7, map[string][]byte{"headers": nil, "bodies": nil}); !errors.Is(err, ErrAppendOrder) {
+		t.Fatalf("append out of order: %v, want %v", err, ErrAppendOrder)
+	}
+	if err := f.Append(5, map[string][]byte{"headers": nil}); !errors.Is(err, ErrMissingItem) {
+		t.Fatalf("append missing a table: %v, want %v", err, ErrMissingItem)
+	}
+	if _, err := f.Retrieve("headers", 5); !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("retrieve past the end: %v, want %v", err, ErrOutOfBounds)
+	}
+	if _, err := f.Retrieve("receipts", 0); !errors.Is(err, ErrUnknownTable) {
+		t.Fatalf("retrieve from an unknown table: %v, want %v", err, ErrUnknownTable)
+	}
+	if err := f.Truncate(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = New(dir, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Items() != 3 {
+		t.Fatalf("reopened freezer holds %d items, want 3", f.Items())
+	}
+	for number := uint64(0); number < 3; number++ {
+		got, err := f.Retrieve("bodies", number)
+		if want := fmt.Sprintf("bodies-%d", number); err != nil || string(got) != want {
+			t.Fatalf("item %d is %q, %v, want %q", number, got, err, want)
+		}
+	}
+}
+
+// tables left at different lengths are cut back to the shortest on open
+func TestFreezerRepair(t *testing.T) {
+	dir := t.TempDir()
+	f, err := New(dir, []string{"headers"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendItems(t, f, []string{"headers"}, 3)
+	f.Close()
+
+	tables := []string{"headers", "bodies"}
+	f, err = New(dir, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Items() != 0 {
+		t.Fatalf("freezer with an empty table holds %d items", f.Items())
+	}
+	appendItems(t, f, tables, 2)
+	if got, err := f.Retrieve("headers", 1); err != nil || string(got) != "headers-1" {
+		t.Fatalf("item after the repair is %q, %v", got, err)
+	}
+	f.Close()
+}
+
+// a read-only freezer sees what was appended before it was opened and
+// refuses to append
+func TestFreezerReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	tables := []string{"headers"}
+	f, err := New(dir, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	appendItems(t, f, tables, 2)
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := NewReadOnly(dir, tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	appendItems(t, f, tables, 1)
+	if ro.Items() != 2 {
+		t.Fatalf("read-only freezer holds %d items, want 2", ro.Items())
+	}
+	if err := ro.Append(2, map[string][]byte{"headers": nil}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("append to a read-only freezer: %v, want %v", err, ErrReadOnly)
+	}
+
+	missing, err := NewReadOnly(filepath.Join(t.TempDir(), "none"), tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer missing.Close()
+	if missing.Items() != 0 {
+		t.Fatalf("read-only freezer of a missing dir holds %d items", missing.Items())
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package freezer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/snappy"
+)
+
+// size of one index entry
+const indexEntrySize = 8
+
+// table is one append-only kind of data. items live snappy compressed back
+// to back in a data file, and an index file holds the end offset of every
+// item in the data file as 8 big endian bytes, so item i spans from the end
+// of item i-1 to its own end. the caller serialises writes
+type table struct {
+	data  *os.File
+	index *os.File
+	items uint64 // number of items in the table
+	size  uint64 // bytes of the data file in use
+}
+
+// opens the table called name in dir. a writable table is created if it does
+// not exist and repaired after a crash. a read-only table is left untouched
+// and only counts the items that were completely written
+func openTable(dir, name string, readOnly bool) (*table, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), flag, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &table{data: data, index: index}
+	items, size, err := t.scan()
+	if err == nil {
+		if readOnly {
+			t.items, t.size = items, size
+		} else {
+			err = t.truncate(items, size)
+		}
+	}
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// counts the items that are completely on disk, leaving out anything a crash
+// or a writer in another process left half written: a partial index entry,
+// and index entries pointing past the end of the data
+func (t *table) scan() (items, size uint64, err error) {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	items = uint64(stat.Size()) / indexEntrySize
+	if items > 0 {
+		if size, err = t.end(items - 1); err != nil {
+			return 0, 0, err
+		}
+	}
+	stat, err = t.data.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	// an index entry pointing past the data means the data never hit disk
+	for items > 0 && size > uint64(stat.Size()) {
+		items--
+		size = 0
+		if items > 0 {
+			if size, err = t.end(items - 1); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	return items, size, nil
+}
+
+// returns the end offset of item number
+func (t *table) end(number uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(number*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+func (t *table) truncate(items, size uint64) error {
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+// keeps only the first items items
+func (t *table) truncateItems(items uint64) error {
+	if items >= t.items {
+		return nil
+	}
+	size := uint64(0)
+	if items > 0 {
+		var err error
+		if size, err = t.end(items - 1); err != nil {
+			return err
+		}
+	}
+	return t.truncate(items, size)
+}
+
+// writes item as the next item of the table
+func (t *table) append(item []byte) error {
+	blob := snappy.Encode(nil, item)
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(blob))
+	return nil
+}
+
+// reads and decompresses item number
+func (t *table) retrieve(number uint64) ([]byte, error) {
+	if number >= t.items {
+		return nil, ErrOutOfBounds
+	}
+	start := uint64(0)
+	if number > 0 {
+		var err error
+		if start, err = t.end(number - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.end(number)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, fmt.Errorf("freezer index corrupt at item %d", number)
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return snappy.Decode(nil, blob)
+}
+
+func (t *table) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *table) close() error {
+	err := t.data.Close()
+	if ierr := t.index.Close(); err == nil {
+		err = ierr
+	}
+	return err
+}
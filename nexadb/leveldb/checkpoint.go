@@ -0,0 +1,95 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package leveldb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+)
+
+var ErrCheckpointExists = errors.New("checkpoint directory is not empty")
+
+// writes are handed to the checkpoint database in batches of about this many bytes
+const checkpointBatchSize = 1 << 20
+
+// writes a copy of the database as it is now into a new database in dir,
+// while the database stays in use
+func (db *Database) Checkpoint(dir string) error {
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+	return Checkpoint(snap, dir)
+}
+
+// writes every key of snap into a new database in dir. dir must not exist or
+// be empty, and is removed again if the copy fails
+func Checkpoint(snap nexadb.Snapshot, dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%w: %s", ErrCheckpointExists, dir)
+	}
+	if err := writeCheckpoint(snap, dir); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+func writeCheckpoint(snap nexadb.Snapshot, dir string) error {
+	out, err := New(dir)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	iter := snap.NewIterator(nil)
+	defer iter.Release()
+
+	batch := out.NewBatch()
+	for iter.Next() {
+		if err := batch.Put(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= checkpointBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	return out.Close()
+}
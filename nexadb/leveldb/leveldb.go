@@ -29,16 +29,25 @@ package leveldb
 import (
 	"errors"
 	"fmt"
+	"os"
 
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
 	ErrNotFound = errors.New("item not found in leveldb database")
+
+	// returned by writes to a database opened with NewReadOnly
+	ErrReadOnly = leveldb.ErrReadOnly
 )
 
+var _ nexadb.KeyValueStore = (*Database)(nil)
+
 type Database struct {
-	db *leveldb.DB
+	db   *leveldb.DB
+	view string // private copy opened by NewReadOnly, removed on Close
 }
 
 func New(path string) (*Database, error) {
@@ -49,6 +58,15 @@ func New(path string) (*Database, error) {
 	return &Database{db: db}, nil
 }
 
+// closes the database, it cannot be used afterwards
+func (db *Database) Close() error {
+	err := db.db.Close()
+	if db.view != "" {
+		os.RemoveAll(db.view)
+	}
+	return err
+}
+
 // inserts bytes into the leveldb database
 func (db *Database) Put(key []byte, value []byte) error {
 	return db.db.Put(key, value, nil)
@@ -56,7 +74,93 @@ func (db *Database) Put(key []byte, value []byte) error {
 
 // retrieves a value from the database given a key
 func (db *Database) Get(key []byte) ([]byte, error) {
-	return db.db.Get(key, nil)
+	value, err := db.db.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+// reports whether a key is present in the database
+func (db *Database) Has(key []byte) (bool, error) {
+	return db.db.Has(key, nil)
+}
+
+// returns an iterator over every key starting with prefix, in key order.
+// the iterator must be released once the caller is done with it
+func (db *Database) NewIterator(prefix []byte) nexadb.Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+// returns a batch whose writes reach the database together on Write
+func (db *Database) NewBatch() nexadb.Batch {
+	return &batch{db: db.db, b: new(leveldb.Batch)}
+}
+
+// returns a consistent view of the database as it is now, which later
+// writes do not change. the snapshot must be released once the caller is
+// done with it
+func (db *Database) NewSnapshot() (nexadb.Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{snap: snap}, nil
+}
+
+type snapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *snapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	value, err := s.snap.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *snapshot) NewIterator(prefix []byte) nexadb.Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *snapshot) Release() {
+	s.snap.Release()
+}
+
+type batch struct {
+	db   *leveldb.DB
+	b    *leveldb.Batch
+	size int
+}
+
+func (b *batch) Put(key []byte, value []byte) error {
+	b.b.Put(key, value)
+	b.size += len(key) + len(value)
+	return nil
+}
+
+func (b *batch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += len(key)
+	return nil
+}
+
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+func (b *batch) Write() error {
+	return b.db.Write(b.b, nil)
+}
+
+func (b *batch) Reset() {
+	b.b.Reset()
+	b.size = 0
 }
 
 // removes an item from the database given a key
@@ -0,0 +1,194 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package leveldb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// times NewReadOnly tries to copy a database that keeps changing under it
+const readOnlyAttempts = 5
+
+// age after which a view nobody has open is taken to be left behind by a
+// process that did not get to Close, younger ones may still be being copied
+const staleViewAge = time.Minute
+
+// opens the database at path for reading only, even while another process
+// has it open. leveldb lets a single process lock a database, so rather than
+// path itself a private view of it is opened: the table files, which leveldb
+// never changes once written, are hard linked into a hidden directory next
+// to the database and the manifest and logs are copied. the view holds the
+// database as it was when NewReadOnly was called and is removed on Close.
+// writes to it fail with ErrReadOnly. views a crashed process left behind
+// are removed first
+func NewReadOnly(path string) (*Database, error) {
+	path = filepath.Clean(path)
+	removeStaleViews(path)
+	var err error
+	for attempt := 0; attempt < readOnlyAttempts; attempt++ {
+		// next to the database, so it is on the same filesystem and the
+		// table files can be linked rather than copied
+		var view string
+		if view, err = os.MkdirTemp(filepath.Dir(path), "."+filepath.Base(path)+"-view-"); err != nil {
+			return nil, err
+		}
+		if err = copyView(path, view); err == nil {
+			var db *leveldb.DB
+			db, err = leveldb.OpenFile(view, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+			if err == nil {
+				return &Database{db: db, view: view}, nil
+			}
+		}
+		// a compaction or a write may have changed the files while they
+		// were copied, so a fresh copy may succeed
+		os.RemoveAll(view)
+	}
+	return nil, fmt.Errorf("opening %s read-only: %w", path, err)
+}
+
+// removes the views of the database at path that are no longer open. an
+// open view holds the lock leveldb takes on it, a view that can be locked
+// is not in use
+func removeStaleViews(path string) {
+	views, err := filepath.Glob(filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+"-view-*"))
+	if err != nil {
+		return
+	}
+	for _, view := range views {
+		info, err := os.Stat(view)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < staleViewAge {
+			continue
+		}
+		stor, err := storage.OpenFile(view, false)
+		if err != nil {
+			continue
+		}
+		stor.Close()
+		os.RemoveAll(view)
+	}
+}
+
+// returns the names of the log files among entries, in name order
+func logFiles(entries []os.DirEntry) []string {
+	var logs []string
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) == ".log" {
+			logs = append(logs, entry.Name())
+		}
+	}
+	return logs
+}
+
+// copies the files leveldb needs to open the database in src into dst. the
+// copy is only good if the database did not move on meanwhile: CURRENT has
+// to name the same manifest, the manifest has to keep its size and the
+// same log files have to be there afterwards
+func copyView(src, dst string) error {
+	current, err := os.ReadFile(filepath.Join(src, "CURRENT"))
+	if err != nil {
+		return err
+	}
+	manifest := strings.TrimSpace(string(current))
+	info, err := os.Stat(filepath.Join(src, manifest))
+	if err != nil {
+		return err
+	}
+	copied, err := copyFile(filepath.Join(src, manifest), filepath.Join(dst, manifest))
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		from, to := filepath.Join(src, name), filepath.Join(dst, name)
+		switch filepath.Ext(name) {
+		case ".ldb", ".sst":
+			// copying every table would take as long as the database is
+			// large, so a view that cannot link them is not made at all
+			if err = os.Link(from, to); err != nil {
+				return fmt.Errorf("linking table files into a read-only view: %w", err)
+			}
+		case ".log":
+			_, err = copyFile(from, to)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// CURRENT goes in last, and only if nothing the view depends on
+	// changed while the files were being copied
+	if now, err := os.ReadFile(filepath.Join(src, "CURRENT")); err != nil || string(now) != string(current) {
+		return fmt.Errorf("manifest of %s changed while copying", src)
+	}
+	if now, err := os.Stat(filepath.Join(src, manifest)); err != nil || now.Size() != info.Size() || copied != info.Size() {
+		return fmt.Errorf("manifest of %s grew while copying", src)
+	}
+	now, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if !slices.Equal(logFiles(now), logFiles(entries)) {
+		return fmt.Errorf("log files of %s changed while copying", src)
+	}
+	return os.WriteFile(filepath.Join(dst, "CURRENT"), current, 0644)
+}
+
+// copies the file at src to dst and returns the number of bytes copied
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+	return n, out.Close()
+}
@@ -0,0 +1,83 @@
+package leveldb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// a view sees the database as it was when it was opened, even while the
+// writer goes on, refuses writes and is removed on Close
+func TestReadOnlyView(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Put([]byte("k"), []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+
+	view, err := NewReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("k"), []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := view.Get([]byte("k")); err != nil || string(got) != "v1" {
+		t.Fatalf("view reads %q, %v, want the value it was opened with", got, err)
+	}
+	if err := view.Put([]byte("k"), []byte("v3")); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("write to a view: %v, want %v", err, ErrReadOnly)
+	}
+	dir := view.view
+	if err := view.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("the view was not removed on Close")
+	}
+}
+
+// a view a crashed process left behind is removed by the next one, a view
+// that is open is left alone
+func TestReadOnlyStaleViews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	open, err := NewReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close()
+	stale, err := os.MkdirTemp(filepath.Dir(path), ".db-view-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleViewAge)
+	for _, dir := range []string{stale, open.view} {
+		if err := os.Chtimes(dir, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	view, err := NewReadOnly(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Close()
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("the stale view was not removed")
+	}
+	if _, err := os.Stat(open.view); err != nil {
+		t.Fatalf("the open view was removed: %v", err)
+	}
+}
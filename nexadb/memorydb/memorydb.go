@@ -2,7 +2,11 @@ package memorydb
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
 )
 
 var (
@@ -10,6 +14,8 @@ var (
 	errMemorydbNotFound = errors.New("not found")
 )
 
+var _ nexadb.KeyValueStore = (*Database)(nil)
+
 type Database struct {
 	items map[string][]byte
 	lock  sync.RWMutex
@@ -78,3 +84,166 @@ func (db *Database) Delete(key []byte) error {
 	delete(db.items, string(key))
 	return nil
 }
+
+// NewBatch returns a batch that applies its writes under one lock.
+func (db *Database) NewBatch() nexadb.Batch {
+	return &batch{db: db}
+}
+
+type write struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+// batch queues writes until Write applies them all at once.
+type batch struct {
+	db     *Database
+	writes []write
+	size   int
+}
+
+// Put queues storing value under key.
+func (b *batch) Put(key []byte, value []byte) error {
+	b.writes = append(b.writes, write{key: string(key), value: append([]byte{}, value...)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete queues removing key.
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, write{key: string(key), delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize returns the number of bytes queued.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write applies the queued writes.
+func (b *batch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	if b.db.items == nil {
+		return errMemorydbClosed
+	}
+	for _, w := range b.writes {
+		if w.delete {
+			delete(b.db.items, w.key)
+		} else {
+			b.db.items[w.key] = w.value
+		}
+	}
+	return nil
+}
+
+// Reset drops the queued writes.
+func (b *batch) Reset() {
+	b.writes, b.size = b.writes[:0], 0
+}
+
+// NewIterator returns an iterator over a snapshot of every key starting with
+// prefix, in key order.
+func (db *Database) NewIterator(prefix []byte) nexadb.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.items {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = db.items[key]
+	}
+	return &iterator{index: -1, keys: keys, values: values}
+}
+
+// NewSnapshot returns a copy of the database that later writes do not reach.
+func (db *Database) NewSnapshot() (nexadb.Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.items == nil {
+		return nil, errMemorydbClosed
+	}
+	items := make(map[string][]byte, len(db.items))
+	for key, value := range db.items {
+		items[key] = append([]byte{}, value...)
+	}
+	return &snapshot{db: &Database{items: items}}, nil
+}
+
+// snapshot serves reads from a private copy of the database.
+type snapshot struct {
+	db *Database
+}
+
+// Has retrieves if a key is present in the snapshot.
+func (s *snapshot) Has(key []byte) (bool, error) {
+	return s.db.Has(key)
+}
+
+// Get retrieves the given key if it's present in the snapshot.
+func (s *snapshot) Get(key []byte) ([]byte, error) {
+	return s.db.Get(key)
+}
+
+// NewIterator returns an iterator over every key of the snapshot starting
+// with prefix, in key order.
+func (s *snapshot) NewIterator(prefix []byte) nexadb.Iterator {
+	return s.db.NewIterator(prefix)
+}
+
+// Release drops the copy, the snapshot cannot be used afterwards.
+func (s *snapshot) Release() {
+	s.db.Close()
+}
+
+// iterator walks a snapshot of the database taken when it was created.
+type iterator struct {
+	index  int
+	keys   []string
+	values [][]byte
+}
+
+// Next moves the iterator to the next key, reporting whether there is one.
+func (it *iterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+// Key returns the key at the iterator's position, nil if there is none.
+func (it *iterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return []byte(it.keys[it.index])
+}
+
+// Value returns the value at the iterator's position, nil if there is none.
+func (it *iterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+// Error returns nil, a snapshot cannot fail.
+func (it *iterator) Error() error {
+	return nil
+}
+
+// Release drops the snapshot.
+func (it *iterator) Release() {
+	it.index, it.keys, it.values = len(it.keys), nil, nil
+}
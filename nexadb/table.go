@@ -0,0 +1,129 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package nexadb
+
+// table gives a subsystem its own namespace inside a shared store by putting
+// a fixed prefix in front of every key
+type table struct {
+	db     KeyValueStore
+	prefix string
+}
+
+// returns a store that keeps every key of db under prefix. keys handed to it
+// and keys read back from its iterators come without the prefix, so a table
+// never sees keys outside of it
+func Table(db KeyValueStore, prefix string) KeyValueStore {
+	return &table{db: db, prefix: prefix}
+}
+
+func (t *table) key(key []byte) []byte {
+	return append([]byte(t.prefix), key...)
+}
+
+func (t *table) Has(key []byte) (bool, error) {
+	return t.db.Has(t.key(key))
+}
+
+func (t *table) Get(key []byte) ([]byte, error) {
+	return t.db.Get(t.key(key))
+}
+
+func (t *table) Put(key []byte, value []byte) error {
+	return t.db.Put(t.key(key), value)
+}
+
+func (t *table) Delete(key []byte) error {
+	return t.db.Delete(t.key(key))
+}
+
+func (t *table) NewIterator(prefix []byte) Iterator {
+	return &tableIterator{
+		Iterator: t.db.NewIterator(t.key(prefix)),
+		prefix:   len(t.prefix),
+	}
+}
+
+func (t *table) NewBatch() Batch {
+	return &tableBatch{Batch: t.db.NewBatch(), prefix: t.prefix}
+}
+
+func (t *table) NewSnapshot() (Snapshot, error) {
+	snap, err := t.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{Snapshot: snap, prefix: t.prefix}, nil
+}
+
+// prefixes the keys read through the underlying snapshot
+type tableSnapshot struct {
+	Snapshot
+	prefix string
+}
+
+func (s *tableSnapshot) Has(key []byte) (bool, error) {
+	return s.Snapshot.Has(append([]byte(s.prefix), key...))
+}
+
+func (s *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return s.Snapshot.Get(append([]byte(s.prefix), key...))
+}
+
+func (s *tableSnapshot) NewIterator(prefix []byte) Iterator {
+	return &tableIterator{
+		Iterator: s.Snapshot.NewIterator(append([]byte(s.prefix), prefix...)),
+		prefix:   len(s.prefix),
+	}
+}
+
+// prefixes the keys written through the underlying batch
+type tableBatch struct {
+	Batch
+	prefix string
+}
+
+func (b *tableBatch) Put(key []byte, value []byte) error {
+	return b.Batch.Put(append([]byte(b.prefix), key...), value)
+}
+
+func (b *tableBatch) Delete(key []byte) error {
+	return b.Batch.Delete(append([]byte(b.prefix), key...))
+}
+
+// strips the table prefix off the keys of the underlying iterator
+type tableIterator struct {
+	Iterator
+	prefix int
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[it.prefix:]
+}
@@ -0,0 +1,69 @@
+package nexadb_test
+
+import (
+	"testing"
+
+	"github.com/PulseCoinOrg/nexacoin/nexadb"
+	"github.com/PulseCoinOrg/nexacoin/nexadb/memorydb"
+)
+
+// returns the keys and values of iter, releasing it
+func collect(t *testing.T, iter nexadb.Iterator) map[string]string {
+	t.Helper()
+	defer iter.Release()
+	kv := make(map[string]string)
+	for iter.Next() {
+		kv[string(iter.Key())] = string(iter.Value())
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return kv
+}
+
+// a table only sees its own keys, without the prefix, through every way
+// of reading and writing
+func TestTable(t *testing.T) {
+	db := memorydb.New()
+	table := nexadb.Table(db, "t")
+	if err := db.Put([]byte("x1"), []byte("outside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put([]byte("a1"), []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	batch := table.NewBatch()
+	batch.Put([]byte("a2"), []byte("v2"))
+	batch.Put([]byte("b1"), []byte("v3"))
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := db.Get([]byte("ta2")); err != nil || string(got) != "v2" {
+		t.Fatalf("batched key stored as %q, %v", got, err)
+	}
+	if ok, _ := table.Has([]byte("x1")); ok {
+		t.Fatal("table sees a key outside of it")
+	}
+	if got := collect(t, table.NewIterator([]byte("a"))); len(got) != 2 || got["a1"] != "v1" || got["a2"] != "v2" {
+		t.Fatalf("iterating the table under a gives %v", got)
+	}
+
+	snap, err := table.NewSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	if err := table.Delete([]byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := snap.Get([]byte("a1")); err != nil || string(got) != "v1" {
+		t.Fatalf("snapshot reads %q, %v after a later delete", got, err)
+	}
+	if got := collect(t, snap.NewIterator(nil)); len(got) != 3 {
+		t.Fatalf("iterating the snapshot gives %v", got)
+	}
+	if got := collect(t, table.NewIterator(nil)); len(got) != 2 {
+		t.Fatalf("iterating the table after the delete gives %v", got)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+ * NexaCoin - A Cryptocurrency Framework
+ *
+ * Copyright (c) 2025 NexaCoin Developers
+ *
+ * This file is part of the NexaCoin project and is licensed under the MIT License.
+ * You may obtain a copy of the License at:
+ *
+ *     https://opensource.org/licenses/MIT
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package params
+
+import (
+	"errors"
+
+	"github.com/PulseCoinOrg/nexacoin/common"
+)
+
+var (
+	ErrInvalidFeeMarket  = errors.New("fee market config: ChangeDenominator must not be zero")
+	ErrInvalidFeeShares  = errors.New("fee config: proposer, burn and treasury shares must add up to 100")
+	ErrInvalidVoterShare = errors.New("issuance config: VoterShare must not be above 100")
+	ErrInvalidPow        = errors.New("pow config: BoundDivisor and GenesisDifficulty must not be zero")
+	ErrInvalidValidators = errors.New("validator config: every genesis validator needs a stake and a distinct address")
+)
+
+const (
+	// smallest units in one NEX
+	Nex uint64 = 100_000_000
+
+	// gas charged for a transfer transaction
+	TxGasTransfer uint64 = 21_000
+	// gas charged for a transaction that stakes or unstakes
+	TxGasStake uint64 = 50_000
+
+	// gas limit blocks move towards by default, room for 1000 transfers
+	DefaultGasLimit uint64 = 1000 * TxGasTransfer
+	// a block's gas limit never drops below this
+	MinGasLimit uint64 = 5 * TxGasTransfer
+	// a block can move the gas limit by less than parent/GasLimitBoundDivisor
+	GasLimitBoundDivisor uint64 = 1024
+
+	// number of blocks in a single validator epoch
+	DefaultEpochLength uint64 = 32
+
+	// number of reveals a validator can make before it has to commit again
+	RandaoOnionLength = 1 << 14
+)
+
+// names of the consensus engines a chain can run
+const (
+	ProofOfStake = "pos"
+	ProofOfWork  = "pow"
+	ProofOfAuth  = "poa"
+)
+
+// shapes of issuance curve a chain can use
+const (
+	IssuanceFixed     = "fixed"     // the same reward every block
+	IssuanceHalving   = "halving"   // the reward halves every HalvingInterval blocks
+	IssuanceInflation = "inflation" // the reward is a yearly percentage of supply
+)
+
+// ChainConfig holds the parameters a chain is started with. every node on
+// a network must use the same config or they will not agree on blocks.
+type ChainConfig struct {
+	// consensus engine used to produce and verify blocks
+	Consensus string
+
+	// stake changes are held as pending in the state and only take effect
+	// at the first block of the next epoch
+	EpochLength uint64
+
+	// validators that hold stake before the first block, used when
+	// Consensus is ProofOfStake
+	Validators []GenesisValidator
+
+	// gas limit producers steer blocks towards
+	GasLimit uint64
+
+	// balances that exist before the first block
+	Alloc map[common.Address]uint64
+
+	// how new coins are paid out to block producers
+	Issuance *IssuanceConfig
+
+	// where transaction fees go
+	Fees *FeeConfig
+
+	// how the base fee follows block fullness
+	FeeMarket *FeeMarketConfig
+
+	// only used when Consensus is ProofOfWork
+	Pow *PowConfig
+
+	// only used when Consensus is ProofOfAuth
+	Poa *PoaConfig
+}
+
+// checks the parts of the config that would make block processing fail or
+// overflow. it is run once when a chain is opened
+func (c *ChainConfig) Validate() error {
+	if c.FeeMarket != nil && c.FeeMarket.ChangeDenominator == 0 {
+		return ErrInvalidFeeMarket
+	}
+	if f := c.Fees; f != nil {
+		// each share is checked on its own first so the sum cannot wrap
+		if f.ProposerShare > 100 || f.BurnShare > 100 || f.TreasuryShare > 100 ||
+			f.ProposerShare+f.BurnShare+f.TreasuryShare != 100 {
+			return ErrInvalidFeeShares
+		}
+	}
+	if c.Issuance != nil && c.Issuance.VoterShare > 100 {
+		return ErrInvalidVoterShare
+	}
+	if c.Pow != nil && (c.Pow.BoundDivisor == 0 || c.Pow.GenesisDifficulty == 0) {
+		return ErrInvalidPow
+	}
+	seen := make(map[common.Address]bool, len(c.Validators))
+	for _, v := range c.Validators {
+		if v.Stake == 0 || seen[v.Address] {
+			return ErrInvalidValidators
+		}
+		seen[v.Address] = true
+	}
+	return nil
+}
+
+// GenesisValidator is a validator the chain starts with. its stake is
+// created at genesis on top of Alloc
+type GenesisValidator struct {
+	Address      common.Address
+	Stake        uint64
+	RandaoCommit common.Hash // end of the validator's hash onion
+}
+
+// PowConfig tunes difficulty retargeting for proof of work test networks
+type PowConfig struct {
+	BlockTime         uint64 // seconds the network aims to spend on each block
+	GenesisDifficulty uint64 // difficulty of the first block
+	MinimumDifficulty uint64 // difficulty never drops below this
+	BoundDivisor      uint64 // a block can move difficulty by at most 1/BoundDivisor
+}
+
+// PoaConfig lists the operators allowed to seal blocks on a private network
+type PoaConfig struct {
+	Signers []common.Address // signers at genesis, later changed by votes
+	Period  uint64           // minimum seconds between blocks
+	Epoch   uint64           // votes are cleared every Epoch blocks
+}
+
+var DefaultPoaConfig = &PoaConfig{
+	Period: 5,
+	Epoch:  30000,
+}
+
+var DefaultPowConfig = &PowConfig{
+	BlockTime:         10,
+	GenesisDifficulty: 1 << 16,
+	MinimumDifficulty: 1 << 10,
+	BoundDivisor:      2048,
+}
+
+var DefaultChainConfig = &ChainConfig{
+	Consensus:   ProofOfStake,
+	EpochLength: DefaultEpochLength,
+	GasLimit:    DefaultGasLimit,
+	Issuance:    DefaultIssuanceConfig,
+	Fees:        DefaultFeeConfig,
+	FeeMarket:   DefaultFeeMarketConfig,
+}
+
+// FeeConfig splits transaction fees, the shares are percentages that must
+// add up to 100. without a treasury address its share goes to the proposer
+type FeeConfig struct {
+	ProposerShare uint64
+	BurnShare     uint64
+	TreasuryShare uint64
+	Treasury      common.Address
+}
+
+var DefaultFeeConfig = &FeeConfig{
+	ProposerShare: 70,
+	BurnShare:     30,
+}
+
+// FeeMarketConfig drives the per block base fee. every block carries a base
+// fee per gas all of its transactions pay, which is burned, and that moves up
+// when the parent used more than its gas target and down when it used less
+type FeeMarketConfig struct {
+	InitialBaseFee       uint64 // base fee of the first block
+	MinBaseFee           uint64 // the base fee never drops below this
+	ElasticityMultiplier uint64 // the gas target is the gas limit divided by this
+	ChangeDenominator    uint64 // a block moves the base fee by at most 1/ChangeDenominator
+}
+
+var DefaultFeeMarketConfig = &FeeMarketConfig{
+	InitialBaseFee:       1000,
+	MinBaseFee:           1,
+	ElasticityMultiplier: 2,
+	ChangeDenominator:    8,
+}
+
+// IssuanceConfig is the block reward schedule
+type IssuanceConfig struct {
+	Curve string
+
+	// reward for the first block, used by the fixed and halving curves
+	BlockReward     uint64
+	HalvingInterval uint64
+
+	// yearly inflation in basis points (100 = 1%), used by the inflation curve
+	InflationBasisPoints uint64
+	BlocksPerYear        uint64
+
+	// percent of each block reward shared between the finality voters of the
+	// commit certificate the block carries, the rest goes to the proposer
+	VoterShare uint64
+}
+
+var DefaultIssuanceConfig = &IssuanceConfig{
+	Curve:           IssuanceHalving,
+	BlockReward:     50 * Nex,
+	HalvingInterval: 210_000,
+}
@@ -42,6 +42,9 @@ var (
 	DefaultWalletPath = "./wallet.key"
 )
 
+// byte length of a single P256 public key coordinate
+const coordLength = 32
+
 type Wallet struct {
 	PublicKey  []byte
 	PrivateKey []byte
@@ -56,13 +59,12 @@ func New() (*Wallet, error) {
 
 	privKeyBytes := key.D.Bytes()
 
-	pubKey := key.PublicKey
-	pubKeyBytes := append(pubKey.X.Bytes(), pubKey.Y.Bytes()...)
+	pubKeyBytes := marshalPubKey(&key.PublicKey)
 
 	return &Wallet{
 		PublicKey:  pubKeyBytes,
 		PrivateKey: privKeyBytes,
-		Address:    common.MakeAddr(pubKeyBytes),
+		Address:    PubKeyToAddress(pubKeyBytes),
 	}, nil
 }
 
@@ -87,15 +89,65 @@ func LoadFromDisk() (*Wallet, error) {
 	priv.PublicKey.Curve = elliptic.P256()
 	priv.PublicKey.X, priv.PublicKey.Y = priv.PublicKey.Curve.ScalarBaseMult(privKeyBytes)
 
-	pubKeyBytes := append(priv.PublicKey.X.Bytes(), priv.PublicKey.Y.Bytes()...)
+	pubKeyBytes := marshalPubKey(&priv.PublicKey)
 
 	return &Wallet{
 		PrivateKey: privKeyBytes,
 		PublicKey:  pubKeyBytes,
-		Address:    common.MakeAddr(pubKeyBytes),
+		Address:    PubKeyToAddress(pubKeyBytes),
 	}, nil
 }
 
 func (w *Wallet) PublicKeyBytes() []byte {
 	return w.PublicKey[:]
 }
+
+// encodes both coordinates padded to a fixed width so the key can be split
+// back apart when verifying signatures
+func marshalPubKey(pub *ecdsa.PublicKey) []byte {
+	out := make([]byte, 2*coordLength)
+	pub.X.FillBytes(out[:coordLength])
+	pub.Y.FillBytes(out[coordLength:])
+	return out
+}
+
+// derives the address of a public key. the address is taken over the
+// coordinates without leading zero bytes, as it was before keys were
+// padded, so wallets keep the addresses they always had
+func PubKeyToAddress(pubKey []byte) common.Address {
+	if len(pubKey) != 2*coordLength {
+		return common.MakeAddr(pubKey)
+	}
+	x := new(big.Int).SetBytes(pubKey[:coordLength])
+	y := new(big.Int).SetBytes(pubKey[coordLength:])
+	return common.MakeAddr(append(x.Bytes(), y.Bytes()...))
+}
+
+func (w *Wallet) privateKey() *ecdsa.PrivateKey {
+	priv := new(ecdsa.PrivateKey)
+	priv.D = new(big.Int).SetBytes(w.PrivateKey)
+	priv.PublicKey.Curve = elliptic.P256()
+	priv.PublicKey.X, priv.PublicKey.Y = priv.PublicKey.Curve.ScalarBaseMult(w.PrivateKey)
+	return priv
+}
+
+// signs a hash with the wallet's private key
+func (w *Wallet) Sign(hash common.Hash) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, w.privateKey(), hash.Bytes())
+}
+
+// checks a signature made by Sign against the signer's public key
+func VerifySignature(pubKey []byte, hash common.Hash, sig []byte) bool {
+	if len(pubKey) != 2*coordLength {
+		return false
+	}
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(pubKey[:coordLength]),
+		Y:     new(big.Int).SetBytes(pubKey[coordLength:]),
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return false
+	}
+	return ecdsa.VerifyASN1(pub, hash.Bytes(), sig)
+}